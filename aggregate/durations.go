@@ -0,0 +1,15 @@
+package aggregate
+
+// Percentile 计算已升序排列数据的百分位数 (p 取 0-100，线性插值)
+func Percentile(sorted []int, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(rank)
+	if lo >= len(sorted)-1 {
+		return float64(sorted[len(sorted)-1])
+	}
+	frac := rank - float64(lo)
+	return float64(sorted[lo]) + frac*float64(sorted[lo+1]-sorted[lo])
+}
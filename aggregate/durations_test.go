@@ -0,0 +1,28 @@
+package aggregate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	sorted := []int{10, 20, 30, 40, 50}
+	tests := []struct {
+		data []int
+		p    float64
+		want float64
+	}{
+		{sorted, 0, 10},
+		{sorted, 50, 30},
+		{sorted, 90, 46},
+		{sorted, 100, 50},
+		{[]int{15, 45}, 50, 30},
+		{[]int{7}, 90, 7},
+		{nil, 50, 0},
+	}
+	for _, tt := range tests {
+		if got := Percentile(tt.data, tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Percentile(%v, %v) = %v, want %v", tt.data, tt.p, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+// Package aggregate 统一的统计聚合引擎，所有统计接口共用，保证口径一致:
+// 时长统一以分钟累加，小时数保留两位小数，占比为 0-1 之间的小数。
+package aggregate
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GroupBy 分组维度
+type GroupBy string
+
+const (
+	ByTag     GroupBy = "tag"
+	ByWeekday GroupBy = "weekday" // 0=周一 ... 6=周日
+	ByHour    GroupBy = "hour"    // 0-23
+)
+
+// Metric 统计指标
+type Metric string
+
+const (
+	Count      Metric = "count"
+	Minutes    Metric = "minutes"
+	Hours      Metric = "hours"
+	Ratio      Metric = "ratio" // 占查询范围总时长的比例 (0-1)
+	AvgMinutes Metric = "avg_minutes"
+)
+
+// AllMetrics 全部支持的指标
+var AllMetrics = []Metric{Count, Minutes, Hours, Ratio, AvgMinutes}
+
+// Query 聚合查询条件
+type Query struct {
+	From        time.Time // 起始日期 (含)，仅取年月日
+	To          time.Time // 结束日期 (含)，仅取年月日
+	Location    *time.Location
+	Granularity Granularity
+	GroupBy     []GroupBy
+	Tag         string // 仅统计该标签，为空时统计全部
+	SundayFirst bool   // 按周聚合时以周日为一周的第一天，默认周一
+	FillEmpty   bool   // 不分组时补齐没有数据的周期
+}
+
+// Bucket 聚合结果中的一个分桶，未参与分组的维度为零值 (Weekday/Hour 为 -1)
+type Bucket struct {
+	Period  string    // 周期标识，无时间粒度时为空
+	Start   time.Time // 周期起始时间
+	Tag     string
+	Weekday int
+	Hour    int
+	Count   int
+	Minutes int
+}
+
+// Result 聚合结果
+type Result struct {
+	Start        time.Time // 查询范围起点 (含)
+	End          time.Time // 查询范围终点 (不含)
+	TotalCount   int
+	TotalMinutes int
+	Buckets      []Bucket
+}
+
+type bucketKey struct {
+	start   int64
+	tag     string
+	weekday int
+	hour    int
+}
+
+// Range 返回查询范围在目标时区下的起止时间 [start, end)
+func (q Query) Range() (time.Time, time.Time) {
+	loc := q.location()
+	start := time.Date(q.From.Year(), q.From.Month(), q.From.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(q.To.Year(), q.To.Month(), q.To.Day()+1, 0, 0, 0, 0, loc)
+	return start, end
+}
+
+func (q Query) location() *time.Location {
+	if q.Location == nil {
+		return time.UTC
+	}
+	return q.Location
+}
+
+func (q Query) groups(g GroupBy) bool {
+	for _, v := range q.GroupBy {
+		if v == g {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 对输入行执行聚合，范围之外的行会被忽略
+func Run(rows []Row, q Query) Result {
+	loc := q.location()
+	start, end := q.Range()
+	res := Result{Start: start, End: end}
+
+	byTag, byWeekday, byHour := q.groups(ByTag), q.groups(ByWeekday), q.groups(ByHour)
+	buckets := make(map[bucketKey]*Bucket)
+
+	for _, r := range rows {
+		t := r.Time.In(loc)
+		if t.Before(start) || !t.Before(end) || (q.Tag != "" && r.Tag != q.Tag) {
+			continue
+		}
+		res.TotalCount += r.Count
+		res.TotalMinutes += r.Minutes
+
+		b := Bucket{Weekday: -1, Hour: -1}
+		if q.Granularity != None {
+			b.Start = periodStart(t, q.Granularity, q.SundayFirst)
+			b.Period = periodLabel(b.Start, q.Granularity, q.SundayFirst)
+		}
+		if byTag {
+			b.Tag = r.Tag
+		}
+		if byWeekday {
+			b.Weekday = (int(t.Weekday()) + 6) % 7
+		}
+		if byHour {
+			b.Hour = t.Hour()
+		}
+
+		key := bucketKey{b.Start.Unix(), b.Tag, b.Weekday, b.Hour}
+		if _, ok := buckets[key]; !ok {
+			buckets[key] = &b
+		}
+		buckets[key].Count += r.Count
+		buckets[key].Minutes += r.Minutes
+	}
+
+	// 补齐空周期 (仅不分组时有意义)
+	if q.FillEmpty && len(q.GroupBy) == 0 {
+		if q.Granularity == None {
+			key := bucketKey{time.Time{}.Unix(), "", -1, -1}
+			if _, ok := buckets[key]; !ok {
+				buckets[key] = &Bucket{Weekday: -1, Hour: -1}
+			}
+		} else {
+			for p := periodStart(start, q.Granularity, q.SundayFirst); p.Before(end); p = nextPeriod(p, q.Granularity) {
+				key := bucketKey{p.Unix(), "", -1, -1}
+				if _, ok := buckets[key]; !ok {
+					buckets[key] = &Bucket{
+						Period:  periodLabel(p, q.Granularity, q.SundayFirst),
+						Start:   p,
+						Weekday: -1,
+						Hour:    -1,
+					}
+				}
+			}
+		}
+	}
+
+	res.Buckets = make([]Bucket, 0, len(buckets))
+	for _, b := range buckets {
+		res.Buckets = append(res.Buckets, *b)
+	}
+	sort.Slice(res.Buckets, func(i, j int) bool {
+		a, b := res.Buckets[i], res.Buckets[j]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		if a.Tag != b.Tag {
+			return a.Tag < b.Tag
+		}
+		if a.Weekday != b.Weekday {
+			return a.Weekday < b.Weekday
+		}
+		return a.Hour < b.Hour
+	})
+
+	return res
+}
+
+// Hours 分桶时长 (小时，保留两位小数)
+func (b Bucket) Hours() float64 {
+	return RoundHours(b.Minutes)
+}
+
+// AvgMinutes 分桶内单条记录平均时长 (分钟，保留两位小数)
+func (b Bucket) AvgMinutes() float64 {
+	if b.Count == 0 {
+		return 0
+	}
+	return Round(float64(b.Minutes)/float64(b.Count), 2)
+}
+
+// Ratio 分桶时长占查询范围总时长的比例 (0-1，保留四位小数)
+func (r Result) Ratio(b Bucket) float64 {
+	if r.TotalMinutes == 0 {
+		return 0
+	}
+	return Round(float64(b.Minutes)/float64(r.TotalMinutes), 4)
+}
+
+// Value 按指标取分桶的值
+func (r Result) Value(b Bucket, m Metric) float64 {
+	switch m {
+	case Count:
+		return float64(b.Count)
+	case Minutes:
+		return float64(b.Minutes)
+	case Hours:
+		return b.Hours()
+	case Ratio:
+		return r.Ratio(b)
+	case AvgMinutes:
+		return b.AvgMinutes()
+	}
+	return 0
+}
+
+// Total 将整个查询范围视为一个分桶
+func (r Result) Total() Bucket {
+	return Bucket{Weekday: -1, Hour: -1, Count: r.TotalCount, Minutes: r.TotalMinutes}
+}
+
+// SortByMinutes 按时长降序排列分桶，时长相同按标签名排序
+func SortByMinutes(buckets []Bucket) {
+	sort.SliceStable(buckets, func(i, j int) bool {
+		if buckets[i].Minutes != buckets[j].Minutes {
+			return buckets[i].Minutes > buckets[j].Minutes
+		}
+		return buckets[i].Tag < buckets[j].Tag
+	})
+}
+
+// ParseGroupBy 解析逗号分隔的分组参数
+func ParseGroupBy(s string) ([]GroupBy, bool) {
+	var groups []GroupBy
+	for _, part := range splitList(s) {
+		switch g := GroupBy(part); g {
+		case ByTag, ByWeekday, ByHour:
+			groups = append(groups, g)
+		default:
+			return nil, false
+		}
+	}
+	return groups, true
+}
+
+// ParseMetrics 解析逗号分隔的指标参数，为空时返回全部指标
+func ParseMetrics(s string) ([]Metric, bool) {
+	parts := splitList(s)
+	if len(parts) == 0 {
+		return AllMetrics, true
+	}
+	var metrics []Metric
+	for _, part := range parts {
+		switch m := Metric(part); m {
+		case Count, Minutes, Hours, Ratio, AvgMinutes:
+			metrics = append(metrics, m)
+		default:
+			return nil, false
+		}
+	}
+	return metrics, true
+}
+
+// RoundHours 分钟换算为小时 (保留两位小数)
+func RoundHours(minutes int) float64 {
+	return Round(float64(minutes)/60.0, 2)
+}
+
+// Round 四舍五入保留 n 位小数
+func Round(v float64, n int) float64 {
+	p := math.Pow(10, float64(n))
+	return math.Round(v*p) / p
+}
+
+func splitList(s string) []string {
+	var parts []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
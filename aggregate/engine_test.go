@@ -0,0 +1,167 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRun(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	rows := []Row{
+		{Time: time.Date(2026, 2, 15, 15, 59, 0, 0, time.UTC), Tag: "工作", Count: 1, Minutes: 10}, // 02-15 23:59 (+8)
+		{Time: time.Date(2026, 2, 15, 16, 0, 0, 0, time.UTC), Tag: "工作", Count: 1, Minutes: 30},  // 02-16 00:00 (+8)
+		{Time: time.Date(2026, 2, 16, 1, 0, 0, 0, time.UTC), Tag: "学习", Count: 2, Minutes: 60},   // 02-16 09:00 (+8)
+		{Time: time.Date(2026, 2, 17, 12, 0, 0, 0, time.UTC), Tag: "工作", Count: 1, Minutes: 90},  // 02-17 20:00 (+8)
+		{Time: time.Date(2026, 2, 22, 16, 0, 0, 0, time.UTC), Tag: "工作", Count: 1, Minutes: 999}, // 02-23 00:00 (+8)，范围外
+	}
+	q := Query{From: date("2026-02-16"), To: date("2026-02-22"), Location: shanghai, GroupBy: []GroupBy{ByTag}}
+
+	res := Run(rows, q)
+	if res.TotalCount != 4 || res.TotalMinutes != 180 {
+		t.Fatalf("total = %d / %d, want 4 / 180", res.TotalCount, res.TotalMinutes)
+	}
+	if len(res.Buckets) != 2 {
+		t.Fatalf("buckets = %+v", res.Buckets)
+	}
+	for _, b := range res.Buckets {
+		switch b.Tag {
+		case "工作":
+			if b.Count != 2 || b.Minutes != 120 || res.Ratio(b) != 0.6667 {
+				t.Errorf("工作 = %+v, ratio %v", b, res.Ratio(b))
+			}
+		case "学习":
+			if b.Count != 2 || b.Minutes != 60 || b.AvgMinutes() != 30 || b.Hours() != 1 {
+				t.Errorf("学习 = %+v", b)
+			}
+		default:
+			t.Errorf("unexpected bucket %+v", b)
+		}
+		if b.Weekday != -1 || b.Hour != -1 {
+			t.Errorf("未分组的维度应为 -1: %+v", b)
+		}
+	}
+
+	// 只统计一个标签
+	q.Tag = "学习"
+	if res := Run(rows, q); res.TotalMinutes != 60 {
+		t.Errorf("Tag 过滤后 TotalMinutes = %d", res.TotalMinutes)
+	}
+
+	// 按星期与小时分组
+	q = Query{From: date("2026-02-16"), To: date("2026-02-22"), Location: shanghai, GroupBy: []GroupBy{ByWeekday, ByHour}}
+	res = Run(rows, q)
+	want := []struct{ weekday, hour, minutes int }{{0, 0, 30}, {0, 9, 60}, {1, 20, 90}}
+	if len(res.Buckets) != len(want) {
+		t.Fatalf("buckets = %+v", res.Buckets)
+	}
+	for i, w := range want {
+		if b := res.Buckets[i]; b.Weekday != w.weekday || b.Hour != w.hour || b.Minutes != w.minutes {
+			t.Errorf("bucket %d = %+v, want %+v", i, b, w)
+		}
+	}
+}
+
+func TestRunFillEmpty(t *testing.T) {
+	rows := []Row{{Time: time.Date(2026, 2, 17, 8, 0, 0, 0, time.UTC), Tag: "工作", Count: 1, Minutes: 30}}
+
+	q := Query{From: date("2026-02-16"), To: date("2026-02-19"), Granularity: Day, FillEmpty: true}
+	res := Run(rows, q)
+	var labels []string
+	for _, b := range res.Buckets {
+		labels = append(labels, b.Period)
+	}
+	if len(labels) != 4 || labels[0] != "2026-02-16" || labels[3] != "2026-02-19" || res.Buckets[1].Minutes != 30 {
+		t.Errorf("buckets = %+v", res.Buckets)
+	}
+
+	// 无时间粒度且没有数据时仍返回一个空分桶
+	q = Query{From: date("2026-03-01"), To: date("2026-03-01"), FillEmpty: true}
+	if res := Run(rows, q); len(res.Buckets) != 1 || res.Buckets[0].Count != 0 {
+		t.Errorf("buckets = %+v", res.Buckets)
+	}
+
+	// 分组时不补齐
+	q = Query{From: date("2026-02-16"), To: date("2026-02-19"), Granularity: Day, FillEmpty: true, GroupBy: []GroupBy{ByTag}}
+	if res := Run(rows, q); len(res.Buckets) != 1 {
+		t.Errorf("buckets = %+v", res.Buckets)
+	}
+}
+
+func TestPeriods(t *testing.T) {
+	tests := []struct {
+		day         string
+		g           Granularity
+		sundayFirst bool
+		start       string
+		label       string
+		next        string
+	}{
+		{"2026-02-21", Day, false, "2026-02-21", "2026-02-21", "2026-02-22"},
+		{"2026-02-21", Week, false, "2026-02-16", "2026-W08", "2026-02-23"},
+		{"2026-02-22", Week, false, "2026-02-16", "2026-W08", "2026-02-23"},
+		{"2026-02-22", Week, true, "2026-02-22", "2026-W09", "2026-03-01"},
+		{"2026-01-01", Week, false, "2025-12-29", "2026-W01", "2026-01-05"},
+		{"2026-02-21", Month, false, "2026-02-01", "2026-02", "2026-03-01"},
+		{"2026-01-31", Month, false, "2026-01-01", "2026-01", "2026-02-01"},
+		{"2026-05-20", Quarter, false, "2026-04-01", "2026-Q2", "2026-07-01"},
+		{"2026-12-31", Quarter, false, "2026-10-01", "2026-Q4", "2027-01-01"},
+		{"2026-07-04", Year, false, "2026-01-01", "2026", "2027-01-01"},
+	}
+	for _, tt := range tests {
+		start := periodStart(date(tt.day), tt.g, tt.sundayFirst)
+		if s := start.Format("2006-01-02"); s != tt.start {
+			t.Errorf("periodStart(%s, %s, %v) = %s, want %s", tt.day, tt.g, tt.sundayFirst, s, tt.start)
+		}
+		if l := periodLabel(start, tt.g, tt.sundayFirst); l != tt.label {
+			t.Errorf("periodLabel(%s, %s, %v) = %s, want %s", tt.start, tt.g, tt.sundayFirst, l, tt.label)
+		}
+		if n := nextPeriod(start, tt.g).Format("2006-01-02"); n != tt.next {
+			t.Errorf("nextPeriod(%s, %s) = %s, want %s", tt.start, tt.g, n, tt.next)
+		}
+	}
+}
+
+func TestQueryRange(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	start, end := Query{From: date("2026-02-16"), To: date("2026-02-22"), Location: shanghai}.Range()
+	if !start.Equal(time.Date(2026, 2, 15, 16, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 2, 22, 16, 0, 0, 0, time.UTC)) {
+		t.Errorf("Range = %v ~ %v", start, end)
+	}
+}
+
+func TestParsers(t *testing.T) {
+	if g, ok := ParseGranularity("none"); !ok || g != None {
+		t.Errorf("ParseGranularity(none) = %q, %v", g, ok)
+	}
+	if _, ok := ParseGranularity("hour"); ok {
+		t.Error("ParseGranularity(hour) 应失败")
+	}
+	if groups, ok := ParseGroupBy(" tag, hour "); !ok || len(groups) != 2 || groups[1] != ByHour {
+		t.Errorf("ParseGroupBy = %v, %v", groups, ok)
+	}
+	if _, ok := ParseGroupBy("tag,month"); ok {
+		t.Error("ParseGroupBy(month) 应失败")
+	}
+	if metrics, ok := ParseMetrics(""); !ok || len(metrics) != len(AllMetrics) {
+		t.Errorf("ParseMetrics(\"\") = %v, %v", metrics, ok)
+	}
+	if _, ok := ParseMetrics("count,max"); ok {
+		t.Error("ParseMetrics(max) 应失败")
+	}
+}
+
+func TestSortByMinutes(t *testing.T) {
+	buckets := []Bucket{{Tag: "b", Minutes: 10}, {Tag: "c", Minutes: 30}, {Tag: "a", Minutes: 10}}
+	SortByMinutes(buckets)
+	if buckets[0].Tag != "c" || buckets[1].Tag != "a" || buckets[2].Tag != "b" {
+		t.Errorf("buckets = %+v", buckets)
+	}
+}
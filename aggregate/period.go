@@ -0,0 +1,90 @@
+package aggregate
+
+import (
+	"fmt"
+	"time"
+)
+
+// Granularity 时间粒度
+type Granularity string
+
+const (
+	None    Granularity = ""
+	Day     Granularity = "day"
+	Week    Granularity = "week"
+	Month   Granularity = "month"
+	Quarter Granularity = "quarter"
+	Year    Granularity = "year"
+)
+
+// ParseGranularity 解析粒度参数，"none" 与空字符串表示不按时间切分
+func ParseGranularity(s string) (Granularity, bool) {
+	switch g := Granularity(s); g {
+	case None, Day, Week, Month, Quarter, Year:
+		return g, true
+	case "none":
+		return None, true
+	}
+	return None, false
+}
+
+// periodStart 返回 t 所在周期的起始时间 (t 已转换到目标时区)
+func periodStart(t time.Time, g Granularity, sundayFirst bool) time.Time {
+	y, m, d := t.Date()
+	loc := t.Location()
+	switch g {
+	case Day:
+		return time.Date(y, m, d, 0, 0, 0, 0, loc)
+	case Week:
+		offset := (int(t.Weekday()) + 6) % 7 // 距周一的天数
+		if sundayFirst {
+			offset = int(t.Weekday())
+		}
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, loc)
+	case Month:
+		return time.Date(y, m, 1, 0, 0, 0, 0, loc)
+	case Quarter:
+		return time.Date(y, m-(m-1)%3, 1, 0, 0, 0, 0, loc)
+	case Year:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, loc)
+	}
+	return time.Time{}
+}
+
+// nextPeriod 返回下一个周期的起始时间
+func nextPeriod(start time.Time, g Granularity) time.Time {
+	switch g {
+	case Day:
+		return start.AddDate(0, 0, 1)
+	case Week:
+		return start.AddDate(0, 0, 7)
+	case Month:
+		return start.AddDate(0, 1, 0)
+	case Quarter:
+		return start.AddDate(0, 3, 0)
+	case Year:
+		return start.AddDate(1, 0, 0)
+	}
+	return start
+}
+
+// periodLabel 周期标识: 2026-02-21 / 2026-W08 / 2026-02 / 2026-Q1 / 2026
+func periodLabel(start time.Time, g Granularity, sundayFirst bool) string {
+	switch g {
+	case Day:
+		return start.Format("2006-01-02")
+	case Week:
+		if sundayFirst {
+			start = start.AddDate(0, 0, 1) // 周日开始的周按其后的周一归属 ISO 周
+		}
+		y, w := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", y, w)
+	case Month:
+		return start.Format("2006-01")
+	case Quarter:
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case Year:
+		return start.Format("2006")
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package aggregate
+
+import (
+	"time"
+
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// Row 聚合引擎的输入行，一条记录或一组预聚合的记录
+type Row struct {
+	Time    time.Time
+	Tag     string
+	Count   int
+	Minutes int
+}
+
+// RecordTime 返回行动发生的时间，优先使用 started_at，否则使用 created_at
+//
+// 这是统计的唯一时间口径: 记录是否落在查询范围内、归入哪个分桶都按此时间判断，
+// 数据库端 (stats_hourly、daily_rollups) 使用相同的 coalesce(started_at, created_at)。
+func RecordTime(r models.Record) (time.Time, bool) {
+	if r.StartedAt != "" {
+		if t, ok := utils.ParseTimestamp(r.StartedAt); ok {
+			return t, true
+		}
+	}
+	return utils.ParseTimestamp(r.CreatedAt)
+}
+
+// FromRecords 将原始记录转换为输入行，时间无法解析的记录会被跳过
+func FromRecords(records []models.Record) []Row {
+	rows := make([]Row, 0, len(records))
+	for _, r := range records {
+		t, ok := RecordTime(r)
+		if !ok {
+			continue
+		}
+		rows = append(rows, Row{Time: t, Tag: r.Tag, Count: 1, Minutes: r.Duration})
+	}
+	return rows
+}
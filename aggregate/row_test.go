@@ -0,0 +1,37 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/daily-records-backend/models"
+)
+
+func TestRecordTime(t *testing.T) {
+	tests := []struct {
+		record models.Record
+		want   time.Time
+		ok     bool
+	}{
+		{models.Record{StartedAt: "2026-02-16T09:00:00Z", CreatedAt: "2026-02-16T10:00:00Z"}, time.Date(2026, 2, 16, 9, 0, 0, 0, time.UTC), true},
+		{models.Record{CreatedAt: "2026-02-16T10:00:00+08:00"}, time.Date(2026, 2, 16, 2, 0, 0, 0, time.UTC), true},
+		{models.Record{StartedAt: "无效", CreatedAt: "2026-02-16 10:00:00"}, time.Date(2026, 2, 16, 10, 0, 0, 0, time.UTC), true},
+		{models.Record{CreatedAt: ""}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := RecordTime(tt.record)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("RecordTime(%+v) = %v, %v, want %v, %v", tt.record, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFromRecords(t *testing.T) {
+	rows := FromRecords([]models.Record{
+		{Tag: "工作", Duration: 30, CreatedAt: "2026-02-16T10:00:00Z"},
+		{Tag: "学习", Duration: 20, CreatedAt: "昨天"},
+	})
+	if len(rows) != 1 || rows[0].Tag != "工作" || rows[0].Count != 1 || rows[0].Minutes != 30 {
+		t.Errorf("rows = %+v", rows)
+	}
+}
@@ -0,0 +1,39 @@
+package aggregate
+
+// RollingAverage 计算滑动平均，第 i 项为 values[i-window+1 .. i] 的平均值，
+// 不足 window 项时按已有项计算
+func RollingAverage(values []float64, window int) []float64 {
+	avgs := make([]float64, len(values))
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		n := i + 1
+		if i >= window {
+			sum -= values[i-window]
+			n = window
+		}
+		avgs[i] = Round(sum/float64(n), 2)
+	}
+	return avgs
+}
+
+// LinearSlope 最小二乘法线性回归斜率 (x 为下标 0..n-1)
+func LinearSlope(values []float64) float64 {
+	n := float64(len(values))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range values {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
@@ -0,0 +1,51 @@
+package aggregate
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRollingAverage(t *testing.T) {
+	tests := []struct {
+		values []float64
+		window int
+		want   []float64
+	}{
+		{[]float64{1, 2, 3, 4, 5}, 3, []float64{1, 1.5, 2, 3, 4}},
+		{[]float64{10, 0, 0}, 7, []float64{10, 5, 3.33}},
+		{[]float64{2, 4}, 1, []float64{2, 4}},
+		{nil, 3, []float64{}},
+	}
+	for _, tt := range tests {
+		got := RollingAverage(tt.values, tt.window)
+		if len(got) != len(tt.want) {
+			t.Errorf("RollingAverage(%v, %d) = %v, want %v", tt.values, tt.window, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("RollingAverage(%v, %d) = %v, want %v", tt.values, tt.window, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestLinearSlope(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{1, 3, 5, 7}, 2},
+		{[]float64{5, 5, 5}, 0},
+		{[]float64{4, 2, 0}, -2},
+		{[]float64{0, 1, 0, 1}, 0.2},
+		{[]float64{3}, 0},
+		{nil, 0},
+	}
+	for _, tt := range tests {
+		if got := LinearSlope(tt.values); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("LinearSlope(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
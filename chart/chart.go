@@ -0,0 +1,260 @@
+// Package chart 在服务端绘制统计图表 (趋势折线图、标签饼图、年度热力图)
+//
+// 同一份绘制逻辑分别输出到 SVG 和 PNG 两种画布，供不便使用前端图表库的客户端
+// (小程序、邮件等) 直接展示图片。
+package chart
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// 尺寸限制 (像素)
+const (
+	MinSize = 120
+	MaxSize = 2000
+)
+
+// Theme 配色主题
+type Theme struct {
+	Background string
+	Text       string
+	Muted      string
+	Grid       string
+	Series     []string
+	Heat       [5]string // 热力图 0-4 级颜色
+}
+
+// Themes 内置主题
+var Themes = map[string]Theme{
+	"light": {
+		Background: "#FFFFFF",
+		Text:       "#222222",
+		Muted:      "#777777",
+		Grid:       "#E5E5E5",
+		Series:     []string{"#4E79A7", "#F28E2B", "#E15759", "#76B7B2", "#59A14F", "#EDC948", "#B07AA1", "#FF9DA7", "#9C755F", "#BAB0AC"},
+		Heat:       [5]string{"#EBEDF0", "#9BE9A8", "#40C463", "#30A14E", "#216E39"},
+	},
+	"dark": {
+		Background: "#0D1117",
+		Text:       "#E6EDF3",
+		Muted:      "#8B949E",
+		Grid:       "#30363D",
+		Series:     []string{"#58A6FF", "#F0883E", "#FF7B72", "#56D4DD", "#3FB950", "#E3B341", "#BC8CFF", "#FF9BCE", "#D2A8FF", "#8B949E"},
+		Heat:       [5]string{"#161B22", "#0E4429", "#006D32", "#26A641", "#39D353"},
+	},
+}
+
+// Chart 可绘制的图表
+type Chart interface {
+	draw(c canvas, w, h float64, t Theme)
+}
+
+// anchor 文本水平对齐方式
+type anchor int
+
+const (
+	anchorStart anchor = iota
+	anchorMiddle
+	anchorEnd
+)
+
+type point struct {
+	X, Y float64
+}
+
+// canvas 绘图目标，坐标以左上角为原点
+type canvas interface {
+	rect(x, y, w, h float64, fill string, title string)
+	line(x1, y1, x2, y2, width float64, stroke string)
+	polyline(points []point, width float64, stroke string)
+	circle(cx, cy, r float64, fill string)
+	wedge(cx, cy, r, start, end float64, fill string)
+	text(x, y, size float64, fill string, a anchor, s string)
+}
+
+const titleHeight = 32.0
+
+// drawTitle 绘制标题，返回标题占用的高度
+func drawTitle(c canvas, title string, w float64, t Theme) float64 {
+	if title == "" {
+		return 8
+	}
+	c.text(w/2, 22, 15, t.Text, anchorMiddle, title)
+	return titleHeight
+}
+
+// LineChart 折线图 (如按月的时长趋势)
+type LineChart struct {
+	Title  string
+	Labels []string
+	Values []float64
+}
+
+func (ch LineChart) draw(c canvas, w, h float64, t Theme) {
+	top := drawTitle(c, ch.Title, w, t) + 8
+	const left, right, bottom = 44.0, 16.0, 26.0
+	plotW, plotH := w-left-right, h-top-bottom
+	if plotW <= 0 || plotH <= 0 || len(ch.Values) == 0 {
+		return
+	}
+
+	maxValue := 0.0
+	for _, v := range ch.Values {
+		maxValue = math.Max(maxValue, v)
+	}
+	step := niceStep(maxValue / 4)
+	maxAxis := step * 4
+
+	for i := 0; i <= 4; i++ {
+		y := top + plotH - plotH*float64(i)/4
+		c.line(left, y, left+plotW, y, 1, t.Grid)
+		c.text(left-6, y+4, 10, t.Muted, anchorEnd, formatNumber(step*float64(i)))
+	}
+
+	slot := plotW / float64(len(ch.Values))
+	points := make([]point, len(ch.Values))
+	for i, v := range ch.Values {
+		x := left + slot*float64(i) + slot/2
+		points[i] = point{x, top + plotH - plotH*v/maxAxis}
+		if i < len(ch.Labels) && labelVisible(i, len(ch.Labels), plotW) {
+			c.text(x, h-8, 10, t.Muted, anchorMiddle, ch.Labels[i])
+		}
+	}
+	color := t.Series[0]
+	c.polyline(points, 2, color)
+	for _, p := range points {
+		c.circle(p.X, p.Y, 3, color)
+	}
+}
+
+// PieChart 饼图，右侧附图例
+type PieChart struct {
+	Title  string
+	Labels []string
+	Values []float64
+}
+
+func (ch PieChart) draw(c canvas, w, h float64, t Theme) {
+	top := drawTitle(c, ch.Title, w, t)
+	total := 0.0
+	for _, v := range ch.Values {
+		total += math.Max(v, 0)
+	}
+	if total <= 0 {
+		c.text(w/2, top+(h-top)/2, 12, t.Muted, anchorMiddle, "暂无数据")
+		return
+	}
+
+	r := math.Min(w*0.55, h-top) / 2 * 0.85
+	cx, cy := w*0.3, top+(h-top)/2
+	legendX := cx + r + 24
+	legendY := cy - float64(len(ch.Values))*9
+
+	angle := 0.0
+	for i, v := range ch.Values {
+		if v <= 0 {
+			continue
+		}
+		color := t.Series[i%len(t.Series)]
+		sweep := 2 * math.Pi * v / total
+		c.wedge(cx, cy, r, angle, angle+sweep, color)
+		angle += sweep
+
+		label := ""
+		if i < len(ch.Labels) {
+			label = ch.Labels[i]
+		}
+		y := legendY + float64(i)*18
+		c.rect(legendX, y, 10, 10, color, "")
+		c.text(legendX+16, y+9, 11, t.Text, anchorStart, fmt.Sprintf("%s %.1f%%", label, v*100/total))
+	}
+}
+
+// HeatmapChart 按周排列的每日活跃度热力图
+type HeatmapChart struct {
+	Title       string
+	Start       time.Time // 第一天
+	Levels      []int     // 每天的活跃等级 0-4
+	Minutes     []int     // 每天的时长 (用于 SVG 悬浮提示，可为空)
+	SundayFirst bool
+}
+
+func (ch HeatmapChart) draw(c canvas, w, h float64, t Theme) {
+	top := drawTitle(c, ch.Title, w, t)
+	const left, bottom = 28.0, 8.0
+
+	offset := int(ch.Start.Weekday()+6) % 7 // 周一为第一行
+	weekdays := []string{"一", "", "三", "", "五", "", "日"}
+	if ch.SundayFirst {
+		offset = int(ch.Start.Weekday())
+		weekdays = []string{"日", "", "二", "", "四", "", "六"}
+	}
+	weeks := (offset + len(ch.Levels) + 6) / 7
+	if weeks == 0 {
+		return
+	}
+
+	labelHeight := 14.0
+	cell := math.Min((w-left-8)/float64(weeks), (h-top-labelHeight-bottom)/7)
+	if cell <= 0 {
+		return
+	}
+	gap := math.Max(1, cell*0.15)
+
+	for i, label := range weekdays {
+		if label != "" {
+			c.text(left-6, top+labelHeight+cell*float64(i)+cell*0.75, math.Min(10, cell), t.Muted, anchorEnd, label)
+		}
+	}
+
+	lastMonth := time.Month(0)
+	for i, level := range ch.Levels {
+		day := ch.Start.AddDate(0, 0, i)
+		col, row := (offset+i)/7, (offset+i)%7
+		x := left + cell*float64(col)
+		y := top + labelHeight + cell*float64(row)
+
+		if day.Month() != lastMonth && row == 0 || i == 0 {
+			c.text(x, top+10, math.Min(10, cell*1.2), t.Muted, anchorStart, fmt.Sprintf("%d月", day.Month()))
+			lastMonth = day.Month()
+		}
+
+		if level < 0 || level > 4 {
+			level = 0
+		}
+		title := day.Format("2006-01-02")
+		if i < len(ch.Minutes) {
+			title += fmt.Sprintf(" %d 分钟", ch.Minutes[i])
+		}
+		c.rect(x, y, cell-gap, cell-gap, t.Heat[level], title)
+	}
+}
+
+// niceStep 将刻度间隔取整为 1、2、5 乘以 10 的幂
+func niceStep(raw float64) float64 {
+	if raw <= 0 {
+		return 1
+	}
+	mag := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if raw <= m*mag {
+			return m * mag
+		}
+	}
+	return 10 * mag
+}
+
+// labelVisible 横轴标签过密时隔几个显示一个
+func labelVisible(i, n int, width float64) bool {
+	every := int(math.Ceil(float64(n) * 36 / width))
+	return every <= 1 || i%every == 0
+}
+
+func formatNumber(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
@@ -0,0 +1,165 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+)
+
+var testCharts = []struct {
+	name  string
+	chart Chart
+}{
+	{"折线图-空", LineChart{Title: "趋势"}},
+	{"折线图-单点", LineChart{Labels: []string{"1月"}, Values: []float64{3}}},
+	{"折线图-全零", LineChart{Labels: []string{"1月", "2月", "3月"}, Values: []float64{0, 0, 0}}},
+	{"折线图-标签多于数据", LineChart{Labels: []string{"a", "b", "c"}, Values: []float64{1.5}}},
+	{"饼图-空", PieChart{Title: "标签"}},
+	{"饼图-全零", PieChart{Labels: []string{"工作", "学习"}, Values: []float64{0, 0}}},
+	{"饼图-单项", PieChart{Labels: []string{"工作"}, Values: []float64{5}}},
+	{"饼图-含负数", PieChart{Labels: []string{"工作", "错误", "学习"}, Values: []float64{3, -2, 1}}},
+	{"热力图-空", HeatmapChart{Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	{"热力图-全年", HeatmapChart{Title: "2026", Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Levels: make([]int, 365), SundayFirst: true}},
+	{"热力图-等级越界", HeatmapChart{Start: time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC), Levels: []int{-1, 5, 2}, Minutes: []int{0, 999}}},
+}
+
+// 任何数据都不能在坐标中产生 NaN 或 Inf，且输出必须是合法的 XML
+func TestSVGEdgeCases(t *testing.T) {
+	for _, tt := range testCharts {
+		var buf bytes.Buffer
+		if err := SVG(&buf, tt.chart, 480, 320, Themes["light"]); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		out := buf.String()
+		if strings.Contains(out, "NaN") || strings.Contains(out, "Inf") {
+			t.Errorf("%s: 输出包含 NaN/Inf: %s", tt.name, out)
+		}
+		if !strings.HasPrefix(out, "<svg ") || !strings.HasSuffix(out, "</svg>") {
+			t.Errorf("%s: 输出不是完整的 SVG", tt.name)
+		}
+		if err := checkXML(out); err != nil {
+			t.Errorf("%s: 不是合法的 XML: %v", tt.name, err)
+		}
+	}
+}
+
+func checkXML(s string) error {
+	d := xml.NewDecoder(strings.NewReader(s))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func TestSVGContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		chart Chart
+		want  []string
+	}{
+		{"空饼图提示", PieChart{Values: []float64{0}}, []string{">暂无数据</text>"}},
+		{"单项饼图画整圆", PieChart{Labels: []string{"工作"}, Values: []float64{5}}, []string{"<circle ", ">工作 100.0%</text>"}},
+		{"全零折线图刻度", LineChart{Values: []float64{0, 0}}, []string{">0</text>", ">4</text>"}},
+		{"热力图悬浮提示", HeatmapChart{Start: time.Date(2026, 2, 16, 0, 0, 0, 0, time.UTC), Levels: []int{1}, Minutes: []int{30}}, []string{"<title>2026-02-16 30 分钟</title>", ">2月</text>"}},
+		{
+			"标签转义",
+			PieChart{Title: `<script>alert("x")</script>`, Labels: []string{`A&B <i>`}, Values: []float64{1}},
+			[]string{"&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;", "A&amp;B &lt;i&gt; 100.0%"},
+		},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := SVG(&buf, tt.chart, 480, 320, Themes["dark"]); err != nil {
+			t.Fatal(err)
+		}
+		out := buf.String()
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: 输出缺少 %q:\n%s", tt.name, want, out)
+			}
+		}
+		if strings.Contains(out, "<script>") || strings.Contains(out, "<i>") {
+			t.Errorf("%s: 文本未转义", tt.name)
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	fnt, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := []struct{ w, h int }{{MinSize, MinSize}, {640, 320}, {333, 777}}
+	for _, tt := range testCharts {
+		for _, s := range sizes {
+			for _, f := range []*opentype.Font{nil, fnt} {
+				var buf bytes.Buffer
+				if err := PNG(&buf, tt.chart, s.w, s.h, Themes["dark"], f); err != nil {
+					t.Errorf("%s %dx%d: %v", tt.name, s.w, s.h, err)
+					continue
+				}
+				img, err := png.Decode(&buf)
+				if err != nil {
+					t.Errorf("%s %dx%d: 无法解码 PNG: %v", tt.name, s.w, s.h, err)
+					continue
+				}
+				if b := img.Bounds(); b.Dx() != s.w || b.Dy() != s.h {
+					t.Errorf("%s: 尺寸 = %dx%d, want %dx%d", tt.name, b.Dx(), b.Dy(), s.w, s.h)
+				}
+			}
+		}
+	}
+
+	// 背景使用主题颜色
+	var buf bytes.Buffer
+	if err := PNG(&buf, PieChart{}, 200, 200, Themes["dark"], nil); err != nil {
+		t.Fatal(err)
+	}
+	img, _ := png.Decode(&buf)
+	if r, g, b, _ := img.At(0, 0).RGBA(); r>>8 != 0x0D || g>>8 != 0x11 || b>>8 != 0x17 {
+		t.Errorf("背景色 = %02X%02X%02X, want 0D1117", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestNiceStep(t *testing.T) {
+	tests := []struct {
+		raw, want float64
+	}{
+		{0, 1},
+		{-3, 1},
+		{0.3, 0.5},
+		{1, 1},
+		{1.2, 2},
+		{3, 5},
+		{7, 10},
+		{42, 50},
+		{150, 200},
+	}
+	for _, tt := range tests {
+		if got := niceStep(tt.raw); got != tt.want {
+			t.Errorf("niceStep(%v) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	if c := parseColor("#4E79A7"); c.R != 0x4E || c.G != 0x79 || c.B != 0xA7 || c.A != 0xFF {
+		t.Errorf("parseColor(#4E79A7) = %v", c)
+	}
+	if c := parseColor("red"); c.R != 0 || c.G != 0 || c.B != 0 || c.A != 0xFF {
+		t.Errorf("无效颜色应返回黑色，得到 %v", c)
+	}
+}
@@ -0,0 +1,143 @@
+package chart
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// PNG 将图表栅格化为 PNG
+//
+// fnt 为绘制文字使用的字体，为 nil 时使用内置的点阵字体 (只能显示 ASCII 字符)。
+func PNG(w io.Writer, ch Chart, width, height int, t Theme, fnt *opentype.Font) error {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(parseColor(t.Background)), image.Point{}, draw.Src)
+
+	c := &pngCanvas{img: img, font: fnt, faces: make(map[float64]font.Face)}
+	defer c.close()
+	ch.draw(c, float64(width), float64(height), t)
+	return png.Encode(w, img)
+}
+
+type pngCanvas struct {
+	img   *image.RGBA
+	font  *opentype.Font
+	faces map[float64]font.Face
+	r     *vector.Rasterizer
+}
+
+func (c *pngCanvas) close() {
+	for _, f := range c.faces {
+		f.Close()
+	}
+}
+
+// fill 以非零规则填充多边形 (抗锯齿)
+func (c *pngCanvas) fill(points []point, fill string) {
+	if len(points) < 3 {
+		return
+	}
+	b := c.img.Bounds()
+	if c.r == nil {
+		c.r = vector.NewRasterizer(b.Dx(), b.Dy())
+	} else {
+		c.r.Reset(b.Dx(), b.Dy())
+	}
+	c.r.DrawOp = draw.Over
+	c.r.MoveTo(float32(points[0].X), float32(points[0].Y))
+	for _, p := range points[1:] {
+		c.r.LineTo(float32(p.X), float32(p.Y))
+	}
+	c.r.ClosePath()
+	c.r.Draw(c.img, b, image.NewUniform(parseColor(fill)), image.Point{})
+}
+
+func (c *pngCanvas) rect(x, y, w, h float64, fill string, _ string) {
+	r := image.Rect(int(math.Round(x)), int(math.Round(y)), int(math.Round(x+w)), int(math.Round(y+h)))
+	draw.Draw(c.img, r, image.NewUniform(parseColor(fill)), image.Point{}, draw.Over)
+}
+
+func (c *pngCanvas) line(x1, y1, x2, y2, width float64, stroke string) {
+	dx, dy := x2-x1, y2-y1
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+	// 线段按宽度展开为四边形
+	nx, ny := -dy/length*width/2, dx/length*width/2
+	c.fill([]point{{x1 + nx, y1 + ny}, {x2 + nx, y2 + ny}, {x2 - nx, y2 - ny}, {x1 - nx, y1 - ny}}, stroke)
+}
+
+func (c *pngCanvas) polyline(points []point, width float64, stroke string) {
+	for i := 1; i < len(points); i++ {
+		c.line(points[i-1].X, points[i-1].Y, points[i].X, points[i].Y, width, stroke)
+		c.circle(points[i].X, points[i].Y, width/2, stroke)
+	}
+}
+
+func (c *pngCanvas) circle(cx, cy, r float64, fill string) {
+	c.wedge(cx, cy, r, 0, 2*math.Pi, fill)
+}
+
+func (c *pngCanvas) wedge(cx, cy, r, start, end float64, fill string) {
+	steps := int(math.Ceil((end-start)/(math.Pi/90))) + 1
+	points := make([]point, 0, steps+2)
+	if end-start < 2*math.Pi-1e-9 {
+		points = append(points, point{cx, cy})
+	}
+	for i := 0; i <= steps; i++ {
+		a := start + (end-start)*float64(i)/float64(steps)
+		points = append(points, point{cx + r*math.Sin(a), cy - r*math.Cos(a)})
+	}
+	c.fill(points, fill)
+}
+
+func (c *pngCanvas) text(x, y, size float64, fill string, a anchor, s string) {
+	face := c.face(size)
+	d := &font.Drawer{Dst: c.img, Src: image.NewUniform(parseColor(fill)), Face: face}
+	width := float64(d.MeasureString(s)) / 64
+	switch a {
+	case anchorMiddle:
+		x -= width / 2
+	case anchorEnd:
+		x -= width
+	}
+	d.Dot = fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}
+	d.DrawString(s)
+}
+
+// face 按字号获取字体 (同一次渲染中复用)
+func (c *pngCanvas) face(size float64) font.Face {
+	if c.font == nil {
+		return basicfont.Face7x13
+	}
+	if f, ok := c.faces[size]; ok {
+		return f
+	}
+	f, err := opentype.NewFace(c.font, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return basicfont.Face7x13
+	}
+	c.faces[size] = f
+	return f
+}
+
+// parseColor 解析 #RRGGBB 颜色
+func parseColor(s string) color.RGBA {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil {
+		return color.RGBA{A: 0xFF}
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xFF}
+}
@@ -0,0 +1,80 @@
+package chart
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+)
+
+// svgFonts SVG 文本使用的字体，优先使用客户端的中文字体
+const svgFonts = `-apple-system, "PingFang SC", "Microsoft YaHei", "Noto Sans CJK SC", sans-serif`
+
+// SVG 将图表渲染为 SVG
+func SVG(w io.Writer, ch Chart, width, height int, t Theme) error {
+	c := &svgCanvas{}
+	fmt.Fprintf(&c.buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family='%s'>`,
+		width, height, width, height, svgFonts)
+	fmt.Fprintf(&c.buf, `<rect width="100%%" height="100%%" fill="%s"/>`, t.Background)
+	ch.draw(c, float64(width), float64(height), t)
+	c.buf.WriteString("</svg>")
+	_, err := w.Write(c.buf.Bytes())
+	return err
+}
+
+type svgCanvas struct {
+	buf bytes.Buffer
+}
+
+func (c *svgCanvas) rect(x, y, w, h float64, fill string, title string) {
+	fmt.Fprintf(&c.buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" rx="%.1f" fill="%s"`, x, y, w, h, math.Min(w, h)*0.15, fill)
+	if title == "" {
+		c.buf.WriteString("/>")
+		return
+	}
+	c.buf.WriteString("><title>")
+	xml.EscapeText(&c.buf, []byte(title))
+	c.buf.WriteString("</title></rect>")
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2, width float64, stroke string) {
+	fmt.Fprintf(&c.buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s" stroke-width="%.1f"/>`, x1, y1, x2, y2, stroke, width)
+}
+
+func (c *svgCanvas) polyline(points []point, width float64, stroke string) {
+	c.buf.WriteString(`<polyline fill="none" stroke-linejoin="round" points="`)
+	for i, p := range points {
+		if i > 0 {
+			c.buf.WriteByte(' ')
+		}
+		fmt.Fprintf(&c.buf, "%.1f,%.1f", p.X, p.Y)
+	}
+	fmt.Fprintf(&c.buf, `" stroke="%s" stroke-width="%.1f"/>`, stroke, width)
+}
+
+func (c *svgCanvas) circle(cx, cy, r float64, fill string) {
+	fmt.Fprintf(&c.buf, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`, cx, cy, r, fill)
+}
+
+func (c *svgCanvas) wedge(cx, cy, r, start, end float64, fill string) {
+	if end-start >= 2*math.Pi-1e-9 {
+		c.circle(cx, cy, r, fill)
+		return
+	}
+	x1, y1 := cx+r*math.Sin(start), cy-r*math.Cos(start)
+	x2, y2 := cx+r*math.Sin(end), cy-r*math.Cos(end)
+	large := 0
+	if end-start > math.Pi {
+		large = 1
+	}
+	fmt.Fprintf(&c.buf, `<path d="M%.1f,%.1f L%.1f,%.1f A%.1f,%.1f 0 %d 1 %.1f,%.1f Z" fill="%s"/>`,
+		cx, cy, x1, y1, r, r, large, x2, y2, fill)
+}
+
+func (c *svgCanvas) text(x, y, size float64, fill string, a anchor, s string) {
+	anchors := [...]string{"start", "middle", "end"}
+	fmt.Fprintf(&c.buf, `<text x="%.1f" y="%.1f" font-size="%.1f" fill="%s" text-anchor="%s">`, x, y, size, fill, anchors[a])
+	xml.EscapeText(&c.buf, []byte(s))
+	c.buf.WriteString("</text>")
+}
@@ -0,0 +1,33 @@
+// rebuild-rollups 从 daily_records 原始记录重建 daily_rollups 每日汇总表
+//
+// 用法: SUPABASE_URL=... SUPABASE_KEY=<service_role key> go run ./cmd/rebuild-rollups [-user <user_id>]
+package main
+
+import (
+	"flag"
+
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+func main() {
+	userID := flag.String("user", "", "仅重建指定用户，为空时重建全部用户")
+	flag.Parse()
+
+	utils.InitLogger()
+	defer utils.Logger.Sync()
+
+	utils.InitSupabase()
+
+	params := map[string]interface{}{"p_user_id": nil}
+	if *userID != "" {
+		params["p_user_id"] = *userID
+	}
+
+	var rows int
+	if err := utils.CallRPC("rebuild_daily_rollups", params, &rows); err != nil {
+		utils.Logger.Fatal("重建每日汇总失败", zap.Error(err))
+	}
+
+	utils.Logger.Info("重建每日汇总完成", zap.String("user_id", *userID), zap.Int("rows", rows))
+}
@@ -10,6 +10,7 @@ require (
 	github.com/supabase-community/postgrest-go v0.0.11
 	github.com/supabase-community/supabase-go v0.0.4
 	go.uber.org/zap v1.27.1
+	golang.org/x/image v0.25.0
 )
 
 require (
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+// accountExportTTL 导出完成后归档的保留时间
+const accountExportTTL = time.Hour
+
+// accountExports 导出任务 (任务 ID -> *accountExport)，归档保存在本机临时目录，
+// 多实例部署时下载请求需落在发起导出的实例上
+var accountExports sync.Map
+
+// accountExportStart 保证"检查是否有进行中的任务"与"登记新任务"是原子的，
+// 避免并发的两次点击各自启动一次全量导出
+var accountExportStart sync.Mutex
+
+// accountExport 进行中或已完成的导出任务
+type accountExport struct {
+	mu     sync.Mutex
+	userID string
+	path   string
+	job    models.ExportJob
+}
+
+func (e *accountExport) snapshot() models.ExportJob {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.job
+}
+
+// StartAccountExport 发起 "下载我的全部数据" 任务
+//
+// 后台生成包含记录、标签、生活平衡目标、导出模板与设置的 zip 归档 (均为 JSON，带版本号的
+// manifest.json)，完成后可在一小时内下载。已有进行中的任务时直接返回该任务。
+func StartAccountExport(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	accountExportStart.Lock()
+	defer accountExportStart.Unlock()
+
+	var running *accountExport
+	accountExports.Range(func(_, value interface{}) bool {
+		e := value.(*accountExport)
+		if job := e.snapshot(); e.userID == userID && (job.Status == models.ExportJobPending || job.Status == models.ExportJobRunning) {
+			running = e
+			return false
+		}
+		return true
+	})
+	if running != nil {
+		utils.Success(c, running.snapshot())
+		return
+	}
+
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		utils.Error(c, 500, "创建导出任务失败")
+		return
+	}
+	e := &accountExport{
+		userID: userID,
+		job: models.ExportJob{
+			ID:        hex.EncodeToString(buf),
+			Status:    models.ExportJobPending,
+			CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		},
+	}
+	accountExports.Store(e.job.ID, e)
+	go runAccountExport(e)
+
+	utils.Success(c, e.snapshot())
+}
+
+// GetAccountExport 查询导出任务状态
+func GetAccountExport(c *gin.Context) {
+	e, ok := findAccountExport(c)
+	if !ok {
+		return
+	}
+	utils.Success(c, e.snapshot())
+}
+
+// DownloadAccountExport 下载已完成的归档
+func DownloadAccountExport(c *gin.Context) {
+	e, ok := findAccountExport(c)
+	if !ok {
+		return
+	}
+	job := e.snapshot()
+	if job.Status != models.ExportJobDone {
+		utils.Error(c, 409, "导出尚未完成")
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	setAttachment(c, fmt.Sprintf("daily-records_%s.zip", job.CreatedAt[:10]))
+	c.File(e.path)
+}
+
+// findAccountExport 按路径参数查找当前用户的导出任务，不存在时已写入错误响应
+func findAccountExport(c *gin.Context) (*accountExport, bool) {
+	value, ok := accountExports.Load(c.Param("id"))
+	if !ok || value.(*accountExport).userID != c.GetString("user_id") {
+		utils.Error(c, 404, "导出任务不存在或已过期")
+		return nil, false
+	}
+	return value.(*accountExport), true
+}
+
+// runAccountExport 生成归档，完成 (或失败) 后在保留期满时删除任务与文件
+func runAccountExport(e *accountExport) {
+	e.mu.Lock()
+	e.job.Status = models.ExportJobRunning
+	e.mu.Unlock()
+
+	path, size, err := createAccountArchive(e.userID)
+
+	e.mu.Lock()
+	now := time.Now().UTC()
+	e.job.FinishedAt = now.Format(time.RFC3339)
+	if err != nil {
+		utils.GetLogger().Error("导出账户数据失败", zap.String("user_id", e.userID), zap.Error(err))
+		e.job.Status = models.ExportJobFailed
+		e.job.Error = "导出失败，请稍后重试"
+	} else {
+		e.path = path
+		e.job.Status = models.ExportJobDone
+		e.job.Size = size
+		e.job.DownloadPath = "/api/account/export/" + e.job.ID + "/download"
+		e.job.ExpiresAt = now.Add(accountExportTTL).Format(time.RFC3339)
+	}
+	e.mu.Unlock()
+
+	time.AfterFunc(accountExportTTL, func() {
+		accountExports.Delete(e.job.ID)
+		if path != "" {
+			os.Remove(path)
+		}
+	})
+}
+
+// createAccountArchive 将归档写入临时文件，返回文件路径与大小
+func createAccountArchive(userID string) (string, int64, error) {
+	f, err := os.CreateTemp("", "account-export-*.zip")
+	if err != nil {
+		return "", 0, err
+	}
+	err = writeAccountArchive(f, userID, time.Now().UTC())
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", 0, err
+	}
+	info, err := os.Stat(f.Name())
+	if err != nil {
+		os.Remove(f.Name())
+		return "", 0, err
+	}
+	return f.Name(), info.Size(), nil
+}
+
+// writeAccountArchive 写出账户数据归档
+//
+// 归档内容 (版本 1):
+//   - manifest.json: 格式标识、版本、导出时间与各文件条目数
+//   - records.json: 全部记录
+//   - tags.json: 标签及使用次数、时长
+//   - goals.json: 生活平衡目标 (标签 -> 占比)
+//   - templates.json: 导出模板
+//   - settings.json: 偏好设置
+func writeAccountArchive(w io.Writer, userID string, exportedAt time.Time) error {
+	zw := zip.NewWriter(w)
+	counts := make(map[string]int)
+
+	// 记录逐页写出，同时统计标签使用情况
+	tagUsage := make(map[string]*models.ArchiveTag)
+	for _, tag := range models.AllowedTags {
+		tagUsage[tag] = &models.ArchiveTag{Tag: tag}
+	}
+	rw, err := zw.CreateHeader(&zip.FileHeader{Name: "records.json", Method: zip.Deflate, Modified: exportedAt})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(rw, "["); err != nil {
+		return err
+	}
+	err = scanRecords(userID, time.Time{}, time.Time{}, func(page []models.Record) error {
+		for _, r := range page {
+			r.UserID = ""
+			b, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			sep := ",\n  "
+			if counts["records.json"] == 0 {
+				sep = "\n  "
+			}
+			if _, err := io.WriteString(rw, sep+string(b)); err != nil {
+				return err
+			}
+			counts["records.json"]++
+
+			if tagUsage[r.Tag] == nil {
+				tagUsage[r.Tag] = &models.ArchiveTag{Tag: r.Tag}
+			}
+			tagUsage[r.Tag].Records++
+			tagUsage[r.Tag].Minutes += r.Duration
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(rw, "\n]\n"); err != nil {
+		return err
+	}
+
+	tags := make([]models.ArchiveTag, 0, len(tagUsage))
+	for _, tag := range models.AllowedTags {
+		tags = append(tags, *tagUsage[tag])
+		delete(tagUsage, tag)
+	}
+	// 不在可用标签中的历史标签按名称排在后面
+	others := make([]string, 0, len(tagUsage))
+	for tag := range tagUsage {
+		others = append(others, tag)
+	}
+	sort.Strings(others)
+	for _, tag := range others {
+		tags = append(tags, *tagUsage[tag])
+	}
+
+	settings, err := loadSettings(userID)
+	if err != nil {
+		return err
+	}
+	goals := settings.BalanceTargets
+	if goals == nil {
+		goals = map[string]float64{}
+	}
+
+	templates := make([]models.ExportTemplate, 0)
+	_, err = utils.Client.From("export_templates").
+		Select("*", "", false).
+		Eq("user_id", userID).
+		Order("created_at", &utils.OrderOptions{Ascending: true}).
+		ExecuteTo(&templates)
+	if err != nil {
+		return err
+	}
+	for i := range templates {
+		templates[i].UserID = ""
+	}
+
+	counts["tags.json"] = len(tags)
+	counts["goals.json"] = len(goals)
+	counts["templates.json"] = len(templates)
+	counts["settings.json"] = 1
+	files := []struct {
+		name string
+		v    interface{}
+	}{
+		{"tags.json", tags},
+		{"goals.json", goals},
+		{"templates.json", templates},
+		{"settings.json", models.ArchiveSettings{WeekStart: settings.WeekStart, Timezone: settings.Timezone}},
+		{"manifest.json", models.ArchiveManifest{
+			Format:     models.ArchiveFormat,
+			Version:    models.ArchiveVersion,
+			ExportedAt: exportedAt.Format(time.RFC3339),
+			Counts:     counts,
+		}},
+	}
+	for _, f := range files {
+		b, err := json.MarshalIndent(f.v, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeZipEntry(zw, f.name, exportedAt, string(b)+"\n"); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,399 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/tmpl"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// restoreMaxSize 恢复归档的最大字节数
+	restoreMaxSize = 50 << 20
+	// restoreMaxEntrySize 归档中单个文件解压后的最大字节数
+	restoreMaxEntrySize = 200 << 20
+
+	restoreSkip    = "skip"
+	restoreReplace = "replace"
+)
+
+// uuidPattern 数据库 uuid 主键的格式 (恢复时格式不符的记录 ID 不参与冲突判断)
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// RestoreAccount 将 StartAccountExport 生成的归档恢复到当前账户 (新账户或已有数据的账户均可)
+//
+// multipart 表单字段:
+//   - file: 归档 zip
+//   - conflict: 与已有数据冲突时的处理，skip (默认，保留现有数据) 或 replace (以归档覆盖)
+//   - dry_run: 为 true 时只统计将会新建、覆盖、跳过的条数，不写入
+//
+// 冲突判断: 记录按原 ID 或外部来源标识 (首次恢复时写入 archive:<原 ID>) 匹配，
+// 模板按类型与名称匹配，设置在已保存过设置时视为冲突。标签集合固定，tags.json 仅供查阅。
+func RestoreAccount(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ValidationError(c, "请上传归档文件 (file)")
+		return
+	}
+	if fileHeader.Size > restoreMaxSize {
+		utils.ValidationError(c, fmt.Sprintf("归档不能超过 %d MB", restoreMaxSize>>20))
+		return
+	}
+	conflict := c.DefaultPostForm("conflict", restoreSkip)
+	if conflict != restoreSkip && conflict != restoreReplace {
+		utils.ValidationError(c, "conflict 仅支持 skip、replace")
+		return
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		utils.Error(c, 500, "读取归档失败")
+		return
+	}
+	defer f.Close()
+	zr, err := zip.NewReader(f, fileHeader.Size)
+	if err != nil {
+		utils.ValidationError(c, "归档不是有效的 zip 文件")
+		return
+	}
+
+	var manifest models.ArchiveManifest
+	if ok, err := readArchiveJSON(zr, "manifest.json", &manifest); err != nil || !ok || manifest.Format != models.ArchiveFormat {
+		utils.ValidationError(c, "归档缺少有效的 manifest.json")
+		return
+	}
+	if manifest.Version < 1 || manifest.Version > models.ArchiveVersion {
+		utils.ValidationError(c, fmt.Sprintf("不支持的归档版本 %d (当前支持 %d)", manifest.Version, models.ArchiveVersion))
+		return
+	}
+
+	var records []models.Record
+	var templates []models.ExportTemplate
+	var settings *models.ArchiveSettings
+	var goals map[string]float64
+	for _, file := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"records.json", &records},
+		{"templates.json", &templates},
+		{"settings.json", &settings},
+		{"goals.json", &goals},
+	} {
+		if _, err := readArchiveJSON(zr, file.name, file.v); err != nil {
+			utils.ValidationError(c, fmt.Sprintf("%s 格式不正确", file.name))
+			return
+		}
+	}
+
+	result := models.RestoreResult{
+		DryRun:   c.PostForm("dry_run") == "true",
+		Conflict: conflict,
+		Errors:   make([]models.RestoreError, 0),
+	}
+	steps := []struct {
+		what string
+		run  func() error
+	}{
+		{"设置", func() error { return restoreSettings(userID, settings, goals, &result) }},
+		{"模板", func() error { return restoreTemplates(userID, templates, &result) }},
+		{"记录", func() error { return restoreRecords(userID, records, &result) }},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			utils.GetLogger().Error("恢复账户数据失败", zap.String("user_id", userID), zap.String("step", step.what), zap.Error(err))
+			c.JSON(200, utils.Response{Code: 500, Msg: "恢复" + step.what + "时中断，已完成的部分见结果", Data: result})
+			return
+		}
+	}
+	utils.Success(c, result)
+}
+
+// readArchiveJSON 读取归档中的 JSON 文件，文件不存在时返回 false
+func readArchiveJSON(zr *zip.Reader, name string, v interface{}) (bool, error) {
+	for _, file := range zr.File {
+		if file.Name != name {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return true, err
+		}
+		defer rc.Close()
+		return true, json.NewDecoder(io.LimitReader(rc, restoreMaxEntrySize)).Decode(v)
+	}
+	return false, nil
+}
+
+// restoreSettings 恢复偏好设置与生活平衡目标
+func restoreSettings(userID string, archived *models.ArchiveSettings, goals map[string]float64, result *models.RestoreResult) error {
+	if archived == nil && goals == nil {
+		return nil
+	}
+
+	var rows []models.UserSettings
+	_, err := utils.Client.From("user_settings").
+		Select("*", "", false).
+		Eq("user_id", userID).
+		ExecuteTo(&rows)
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 && result.Conflict == restoreSkip {
+		result.Settings.Skipped++
+		return nil
+	}
+
+	settings := models.DefaultSettings(userID)
+	if len(rows) > 0 {
+		settings = rows[0]
+	}
+	if archived != nil {
+		if archived.WeekStart == models.WeekStartMonday || archived.WeekStart == models.WeekStartSunday {
+			settings.WeekStart = archived.WeekStart
+		}
+		if _, err := utils.LoadLocation(archived.Timezone); err == nil {
+			settings.Timezone = archived.Timezone
+		} else {
+			result.Errors = append(result.Errors, models.RestoreError{File: "settings.json", Index: 1, Message: "timezone 时区不正确，已忽略"})
+		}
+	}
+	if goals != nil {
+		if msg := validateBalanceTargets(goals); msg != "" {
+			result.Errors = append(result.Errors, models.RestoreError{File: "goals.json", Index: 1, Message: msg + "，已忽略"})
+		} else {
+			settings.BalanceTargets = goals
+		}
+	}
+
+	if len(rows) > 0 {
+		result.Settings.Updated++
+	} else {
+		result.Settings.Created++
+	}
+	if result.DryRun {
+		return nil
+	}
+	_, _, err = utils.Client.From("user_settings").Upsert(settings, "user_id", "", "").Execute()
+	if err != nil {
+		return err
+	}
+	utils.GlobalCache.Set(settingsCacheKey(userID), settings)
+	return nil
+}
+
+// restoreTemplates 恢复导出模板，按类型与名称判断冲突
+func restoreTemplates(userID string, templates []models.ExportTemplate, result *models.RestoreResult) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	var existing []models.ExportTemplate
+	_, err := utils.Client.From("export_templates").
+		Select("id,kind,name", "", false).
+		Eq("user_id", userID).
+		ExecuteTo(&existing)
+	if err != nil {
+		return err
+	}
+	byKey := make(map[string]string, len(existing))
+	for _, t := range existing {
+		byKey[t.Kind+"\x00"+t.Name] = t.ID
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for i, t := range templates {
+		if t.Name == "" || len([]rune(t.Name)) > 50 || (t.Kind != models.TemplateKindWeek && t.Kind != models.TemplateKindYear) {
+			result.Errors = append(result.Errors, models.RestoreError{File: "templates.json", Index: i + 1, Message: "name 不能为空且不超过 50 字，kind 仅支持 week、year"})
+			continue
+		}
+		if _, err := tmpl.Parse(t.Body); err != nil {
+			result.Errors = append(result.Errors, models.RestoreError{File: "templates.json", Index: i + 1, Message: err.Error()})
+			continue
+		}
+
+		id, exists := byKey[t.Kind+"\x00"+t.Name]
+		switch {
+		case exists && (result.Conflict == restoreSkip || id == ""): // id 为空表示归档内重名
+			result.Templates.Skipped++
+		case exists:
+			if !result.DryRun {
+				_, _, err = utils.Client.From("export_templates").
+					Update(map[string]interface{}{"body": t.Body, "updated_at": now}, "", "").
+					Eq("id", id).
+					Eq("user_id", userID).
+					Execute()
+				if err != nil {
+					return err
+				}
+			}
+			result.Templates.Updated++
+		default:
+			if !result.DryRun {
+				row := models.ExportTemplate{UserID: userID, Name: t.Name, Kind: t.Kind, Body: t.Body}
+				if _, _, err = utils.Client.From("export_templates").Insert(row, false, "", "", "").Execute(); err != nil {
+					return err
+				}
+			}
+			byKey[t.Kind+"\x00"+t.Name] = ""
+			result.Templates.Created++
+		}
+	}
+	return nil
+}
+
+// restoreRecords 恢复记录: 先按原 ID 与外部来源标识找出已存在的记录，其余分批写入
+func restoreRecords(userID string, records []models.Record, result *models.RestoreResult) error {
+	valid, originalIDs := archiveRecords(userID, records, result)
+
+	existing, err := existingRecordIDs(userID, valid, originalIDs)
+	if err != nil {
+		return err
+	}
+
+	created := make([]models.Record, 0, len(valid))
+	for i, r := range valid {
+		id, ok := existing[i]
+		switch {
+		case !ok:
+			created = append(created, r)
+		case result.Conflict == restoreSkip:
+			result.Records.Skipped++
+		default:
+			if !result.DryRun {
+				update := map[string]interface{}{
+					"content":    r.Content,
+					"tag":        r.Tag,
+					"duration":   r.Duration,
+					"started_at": nullableString(r.StartedAt),
+					"created_at": r.CreatedAt,
+				}
+				_, _, err := utils.Client.From("daily_records").
+					Update(update, "", "").
+					Eq("id", id).
+					Eq("user_id", userID).
+					Execute()
+				if err != nil {
+					return err
+				}
+			}
+			result.Records.Updated++
+		}
+	}
+
+	if result.DryRun {
+		result.Records.Created = len(created)
+		return nil
+	}
+	n, err := insertBatches(created)
+	result.Records.Created = n
+	return err
+}
+
+// archiveRecords 校验归档中的记录并转换为待写入的记录，同时返回各记录在归档中的原 ID
+//
+// 没有外部来源标识的记录以 "archive:" + 原 ID 作为标识，重复导入同一归档时可据此去重；
+// 同一归档中外部来源标识重复的记录只保留第一条，其余计为跳过。
+func archiveRecords(userID string, records []models.Record, result *models.RestoreResult) ([]models.Record, []string) {
+	valid := make([]models.Record, 0, len(records))
+	originalIDs := make([]string, 0, len(records))
+	seen := make(map[string]bool)
+	for i, r := range records {
+		originalID := r.ID
+		if r.ExternalID == "" && originalID != "" {
+			r.ExternalID = "archive:" + originalID
+		}
+		r.ID = ""
+		if _, ok := utils.ParseTimestamp(r.CreatedAt); !ok {
+			result.Errors = append(result.Errors, models.RestoreError{File: "records.json", Index: i + 1, Message: "created_at 格式不正确"})
+			continue
+		}
+		if err := prepareRecord(userID, &r); err != nil {
+			result.Errors = append(result.Errors, models.RestoreError{File: "records.json", Index: i + 1, Message: "行动描述不能为空且长度不超过50字，时长需为非负数"})
+			continue
+		}
+		if r.ExternalID != "" {
+			if seen[r.ExternalID] {
+				result.Records.Skipped++
+				continue
+			}
+			seen[r.ExternalID] = true
+		}
+		valid = append(valid, r)
+		originalIDs = append(originalIDs, originalID)
+	}
+	return valid, originalIDs
+}
+
+// existingRecordIDs 查找与待恢复记录对应的现有记录，返回 待恢复记录下标 -> 现有记录 ID
+func existingRecordIDs(userID string, records []models.Record, originalIDs []string) (map[int]string, error) {
+	byID := make(map[string]int)
+	byExternal := make(map[string]int)
+	for i, r := range records {
+		if uuidPattern.MatchString(originalIDs[i]) {
+			byID[originalIDs[i]] = i
+		}
+		byExternal[r.ExternalID] = i
+	}
+
+	found := make(map[int]string)
+	lookup := func(column string, index map[string]int) error {
+		keys := make([]string, 0, len(index))
+		for k := range index {
+			if k != "" {
+				keys = append(keys, k)
+			}
+		}
+		for start := 0; start < len(keys); start += externalIDChunk {
+			end := start + externalIDChunk
+			if end > len(keys) {
+				end = len(keys)
+			}
+			var rows []models.Record
+			_, err := utils.Client.From("daily_records").
+				Select("id,external_id", "", false).
+				Eq("user_id", userID).
+				In(column, keys[start:end]).
+				ExecuteTo(&rows)
+			if err != nil {
+				return err
+			}
+			for _, row := range rows {
+				key := row.ID
+				if column == "external_id" {
+					key = row.ExternalID
+				}
+				if i, ok := index[key]; ok {
+					found[i] = row.ID
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := lookup("id", byID); err != nil {
+		return nil, err
+	}
+	if err := lookup("external_id", byExternal); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// nullableString 空字符串写入为 null
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
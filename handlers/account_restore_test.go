@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/user/daily-records-backend/models"
+)
+
+func TestArchiveRecords(t *testing.T) {
+	records := []models.Record{
+		{ID: "a", Content: "写代码", Tag: "工作", Duration: 30, CreatedAt: "2026-01-01T10:00:00Z"},
+		{ID: "b", Content: "读书", Tag: "未知", Duration: 20, StartedAt: "2026-01-01T11:00:00Z", CreatedAt: "2026-01-01T11:20:00Z"},
+		{ID: "c", Content: "开会", Tag: "工作", Duration: 60, CreatedAt: "2026-01-02T09:00:00Z", ExternalID: "ics:1"},
+		{ID: "d", Content: "开会", Tag: "工作", Duration: 60, CreatedAt: "2026-01-02T09:00:00Z", ExternalID: "ics:1"},
+		{ID: "e", Content: "", Tag: "工作", Duration: 10, CreatedAt: "2026-01-03T09:00:00Z"},
+		{ID: "f", Content: "跑步", Tag: "休闲", Duration: 10, CreatedAt: "昨天"},
+		{ID: "a", Content: "写代码", Tag: "工作", Duration: 30, CreatedAt: "2026-01-01T10:00:00Z"},
+	}
+
+	var result models.RestoreResult
+	valid, originalIDs := archiveRecords("user", records, &result)
+
+	wantIDs := []string{"a", "b", "c"}
+	if strings.Join(originalIDs, ",") != strings.Join(wantIDs, ",") {
+		t.Fatalf("originalIDs = %v, want %v", originalIDs, wantIDs)
+	}
+	wantExternal := []string{"archive:a", "archive:b", "ics:1"}
+	for i, r := range valid {
+		if r.ExternalID != wantExternal[i] {
+			t.Errorf("valid[%d].ExternalID = %q, want %q", i, r.ExternalID, wantExternal[i])
+		}
+		if r.ID != "" || r.UserID != "user" {
+			t.Errorf("valid[%d] ID = %q, UserID = %q", i, r.ID, r.UserID)
+		}
+	}
+	if valid[1].Tag != "其他" {
+		t.Errorf("未知标签应修正为其他，得到 %q", valid[1].Tag)
+	}
+	if result.Records.Skipped != 2 {
+		t.Errorf("Skipped = %d, want 2", result.Records.Skipped)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Index != 5 || result.Errors[1].Index != 6 {
+		t.Errorf("Errors = %+v", result.Errors)
+	}
+}
+
+func TestRecordRowsUniformKeys(t *testing.T) {
+	// 归档中有的记录带 started_at / external_id，有的没有
+	records := []models.Record{
+		{ID: "a", Content: "写代码", Tag: "工作", Duration: 30, CreatedAt: "2026-01-01T10:00:00Z"},
+		{ID: "b", Content: "读书", Tag: "学习", Duration: 20, StartedAt: "2026-01-01T11:00:00Z", CreatedAt: "2026-01-01T11:20:00Z"},
+		{ID: "c", Content: "开会", Tag: "工作", Duration: 60, CreatedAt: "2026-01-02T09:00:00Z", ExternalID: "ics:1"},
+	}
+	var result models.RestoreResult
+	valid, _ := archiveRecords("user", records, &result)
+	valid = append(valid, models.Record{UserID: "user", Content: "散步", Tag: "休闲", Duration: 15})
+
+	groups := recordRows(valid)
+	if len(groups) != 2 {
+		t.Fatalf("len(groups) = %d, want 2", len(groups))
+	}
+	total := 0
+	for _, rows := range groups {
+		want := rowKeys(rows[0])
+		for _, row := range rows {
+			if got := rowKeys(row); got != want {
+				t.Errorf("同一批写入的字段不一致: %s 与 %s", got, want)
+			}
+		}
+		total += len(rows)
+	}
+	if total != len(valid) {
+		t.Errorf("共 %d 行，want %d", total, len(valid))
+	}
+
+	first := groups[0][0]
+	if first["started_at"] != nil || first["external_id"] != "archive:a" {
+		t.Errorf("first = %v", first)
+	}
+	if _, ok := groups[1][0]["created_at"]; ok {
+		t.Errorf("created_at 为空时应省略: %v", groups[1][0])
+	}
+	if _, ok := groups[1][0]["id"]; ok {
+		t.Errorf("id 为空时应省略: %v", groups[1][0])
+	}
+}
+
+func rowKeys(row map[string]interface{}) string {
+	keys := make([]string, 0, len(row))
+	for k := range row {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
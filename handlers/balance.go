@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// GetBalance 获取按周或按月的生活平衡评分 (与用户设置的目标分配比较)
+//
+// 参数: period=week|month (默认 week)，from/to 日期闭区间 (缺省为最近 8 周或 6 个月)
+func GetBalance(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return
+	}
+	if len(settings.BalanceTargets) == 0 {
+		utils.ValidationError(c, "请先在设置中填写 balance_targets 目标分配")
+		return
+	}
+
+	period := c.DefaultQuery("period", "week")
+	var granularity aggregate.Granularity
+	switch period {
+	case "week":
+		granularity = aggregate.Week
+	case "month":
+		granularity = aggregate.Month
+	default:
+		utils.ValidationError(c, "period 仅支持 week 或 month")
+		return
+	}
+
+	// 日期与周期边界按用户设置的时区计算
+	loc := settingsLocation(settings)
+	today := utils.Today(loc)
+
+	var from, to time.Time
+	if c.Query("from") == "" && c.Query("to") == "" {
+		to = today
+		if granularity == aggregate.Week {
+			from = weekStartOf(to, settings.SundayFirst()).AddDate(0, 0, -7*7)
+		} else {
+			from = time.Date(to.Year(), to.Month()-5, 1, 0, 0, 0, 0, time.UTC)
+		}
+	} else {
+		from, to, err = utils.ParseDateRange(c.Query("from"), c.Query("to"), maxRangeDays)
+		if err != nil {
+			utils.ValidationError(c, "需提供正确的 from 和 to (格式: 2026-02-16，跨度不超过两年)")
+			return
+		}
+	}
+
+	q := aggregate.Query{
+		From:        from,
+		To:          to,
+		Location:    loc,
+		Granularity: granularity,
+		GroupBy:     []aggregate.GroupBy{aggregate.ByTag},
+		SundayFirst: settings.SundayFirst(),
+	}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取平衡数据失败")
+		return
+	}
+
+	resp := models.BalanceResponse{
+		PeriodStatus: periodStatus(from, to, today),
+		From:         from.Format(utils.DateLayout),
+		To:           to.Format(utils.DateLayout),
+		Period:       period,
+		Targets:      settings.BalanceTargets,
+		Periods:      balanceByPeriod(aggregate.Run(rows, q).Buckets, q, settings.BalanceTargets),
+	}
+	utils.Success(c, resp)
+}
+
+// balanceByPeriod 将按周期和标签分组的分桶换算为各周期的平衡评分，没有记录的周期也会返回
+func balanceByPeriod(buckets []aggregate.Bucket, q aggregate.Query, targets map[string]float64) []models.BalanceScore {
+	minutes := make(map[string]map[string]int)
+	for _, b := range buckets {
+		if minutes[b.Period] == nil {
+			minutes[b.Period] = make(map[string]int)
+		}
+		minutes[b.Period][b.Tag] += b.Minutes
+	}
+
+	// 借助 FillEmpty 列出范围内的全部周期
+	periodQ := q
+	periodQ.GroupBy = nil
+	periodQ.FillEmpty = true
+
+	scores := make([]models.BalanceScore, 0)
+	for _, p := range aggregate.Run(nil, periodQ).Buckets {
+		score := balanceScore(minutes[p.Period], targets)
+		score.Period = p.Period
+		score.Start = p.Start.Format(utils.DateLayout)
+		scores = append(scores, score)
+	}
+	return scores
+}
+
+// balanceScore 计算一组标签时长相对目标分配的平衡评分
+func balanceScore(tagMinutes map[string]int, targets map[string]float64) models.BalanceScore {
+	score := models.BalanceScore{Tags: make([]models.TagDeviation, 0)}
+	for _, m := range tagMinutes {
+		score.TotalMinutes += m
+	}
+
+	// 合并目标与实际出现过的标签
+	tags := make(map[string]bool)
+	for tag := range targets {
+		tags[tag] = true
+	}
+	for tag := range tagMinutes {
+		tags[tag] = true
+	}
+
+	diff := 0.0
+	for tag := range tags {
+		d := models.TagDeviation{Tag: tag, TargetPercent: targets[tag]}
+		if score.TotalMinutes > 0 {
+			d.ActualPercent = aggregate.Round(float64(tagMinutes[tag])/float64(score.TotalMinutes)*100, 2)
+		}
+		d.Deviation = aggregate.Round(d.ActualPercent-d.TargetPercent, 2)
+		diff += math.Abs(float64(tagMinutes[tag])/math.Max(float64(score.TotalMinutes), 1)*100 - d.TargetPercent)
+		score.Tags = append(score.Tags, d)
+	}
+	sort.Slice(score.Tags, func(i, j int) bool {
+		if score.Tags[i].TargetPercent != score.Tags[j].TargetPercent {
+			return score.Tags[i].TargetPercent > score.Tags[j].TargetPercent
+		}
+		return score.Tags[i].Tag < score.Tags[j].Tag
+	})
+
+	if score.TotalMinutes > 0 {
+		s := aggregate.Round(100-diff/2, 2)
+		score.Score = &s
+	}
+	return score
+}
+
+// targetsKey 目标分配的缓存键片段，修改目标后包含平衡评分的缓存自然失效
+func targetsKey(targets map[string]float64) string {
+	tags := make([]string, 0, len(targets))
+	for tag := range targets {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	h := fnv.New32a()
+	for _, tag := range tags {
+		fmt.Fprintf(h, "%s=%g;", tag, targets[tag])
+	}
+	return strconv.FormatUint(uint64(h.Sum32()), 16)
+}
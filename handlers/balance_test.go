@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+func TestBalanceScore(t *testing.T) {
+	targets := map[string]float64{"工作": 50, "学习": 30, "休闲": 20}
+	tests := []struct {
+		name    string
+		minutes map[string]int
+		score   float64 // -1 表示没有评分
+		tags    []models.TagDeviation
+	}{
+		{
+			name:    "与目标一致",
+			minutes: map[string]int{"工作": 150, "学习": 90, "休闲": 60},
+			score:   100,
+		},
+		{
+			name:    "部分偏离",
+			minutes: map[string]int{"工作": 60, "学习": 40},
+			score:   80,
+			tags: []models.TagDeviation{
+				{Tag: "工作", TargetPercent: 50, ActualPercent: 60, Deviation: 10},
+				{Tag: "学习", TargetPercent: 30, ActualPercent: 40, Deviation: 10},
+				{Tag: "休闲", TargetPercent: 20, ActualPercent: 0, Deviation: -20},
+			},
+		},
+		{
+			name:    "目标之外的标签",
+			minutes: map[string]int{"家务": 100},
+			score:   0,
+		},
+		{
+			name:    "没有记录",
+			minutes: nil,
+			score:   -1,
+		},
+	}
+	for _, tt := range tests {
+		got := balanceScore(tt.minutes, targets)
+		switch {
+		case tt.score < 0 && got.Score != nil:
+			t.Errorf("%s: Score = %v, want nil", tt.name, *got.Score)
+		case tt.score >= 0 && (got.Score == nil || *got.Score != tt.score):
+			t.Errorf("%s: Score = %v, want %v", tt.name, got.Score, tt.score)
+		}
+		if tt.tags == nil {
+			continue
+		}
+		if len(got.Tags) != len(tt.tags) {
+			t.Errorf("%s: Tags = %+v", tt.name, got.Tags)
+			continue
+		}
+		for i, d := range tt.tags {
+			if got.Tags[i] != d {
+				t.Errorf("%s: Tags[%d] = %+v, want %+v", tt.name, i, got.Tags[i], d)
+			}
+		}
+	}
+}
+
+func TestBalanceByPeriod(t *testing.T) {
+	from, _ := utils.ParseDate("2026-02-01")
+	to, _ := utils.ParseDate("2026-04-30")
+	q := aggregate.Query{From: from, To: to, Granularity: aggregate.Month, GroupBy: []aggregate.GroupBy{aggregate.ByTag}}
+	buckets := []aggregate.Bucket{
+		{Period: "2026-02", Tag: "工作", Minutes: 60},
+		{Period: "2026-04", Tag: "学习", Minutes: 60},
+	}
+
+	scores := balanceByPeriod(buckets, q, map[string]float64{"工作": 100})
+	if len(scores) != 3 {
+		t.Fatalf("scores = %+v", scores)
+	}
+	if scores[0].Period != "2026-02" || scores[0].Start != "2026-02-01" || *scores[0].Score != 100 {
+		t.Errorf("scores[0] = %+v", scores[0])
+	}
+	if scores[1].Period != "2026-03" || scores[1].Score != nil {
+		t.Errorf("没有记录的月份应返回空评分: %+v", scores[1])
+	}
+	if *scores[2].Score != 0 {
+		t.Errorf("scores[2] = %+v", scores[2])
+	}
+}
+
+func TestTargetsKey(t *testing.T) {
+	a := map[string]float64{"工作": 50, "学习": 30, "休闲": 20}
+	b := map[string]float64{"休闲": 20, "学习": 30, "工作": 50}
+	if targetsKey(a) != targetsKey(b) {
+		t.Error("相同的目标分配应得到相同的键")
+	}
+	changed := map[string]float64{"工作": 40, "学习": 40, "休闲": 20}
+	renamed := map[string]float64{"工作": 50, "阅读": 30, "休闲": 20}
+	for _, other := range []map[string]float64{changed, renamed, nil} {
+		if targetsKey(a) == targetsKey(other) {
+			t.Errorf("targetsKey(%v) 与 targetsKey(%v) 不应相同", a, other)
+		}
+	}
+}
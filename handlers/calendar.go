@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/ics"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// feedDefaultDays 订阅日历默认包含的最近天数
+	feedDefaultDays = 90
+	// feedMaxDays 订阅日历最多包含的天数
+	feedMaxDays = 366
+)
+
+// ExportICS 导出时间范围内的记录为 .ics 日历文件。参数: from、to、tz
+func ExportICS(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, loc, ok := parseExportRange(c)
+	if !ok {
+		return
+	}
+	cal, err := buildCalendar(userID, from, to, loc)
+	if err != nil {
+		utils.Error(c, 500, "查询数据失败")
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	setAttachment(c, exportFilename("records", from, to, "ics"))
+	c.Status(200)
+	if err := ics.Write(c.Writer, cal); err != nil {
+		utils.GetLogger().Error("导出日历中断", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// GetCalendarFeed 公开的日历订阅地址 /feed/<token>.ics
+//
+// 日历应用无法携带 Bearer 令牌，以地址中的订阅令牌识别用户。默认包含最近 90 天的记录，
+// 可用 days 调整 (不超过 366)，日期按用户设置的时区划分。错误以 HTTP 状态码加纯文本返回，
+// 日历应用据此判断订阅失效。
+func GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+	userID, err := feedTokenOwner(token)
+	if err != nil {
+		c.String(500, "查询订阅失败")
+		return
+	}
+	if userID == "" {
+		c.String(404, "订阅地址无效或已被吊销")
+		return
+	}
+
+	days := feedDefaultDays
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > feedMaxDays {
+			c.String(400, "days 需为 1 到 366 之间的整数")
+			return
+		}
+		days = n
+	}
+
+	settings, err := loadSettings(userID)
+	if err != nil {
+		c.String(500, "获取设置失败")
+		return
+	}
+	loc := settingsLocation(settings)
+	to := utils.DateOf(time.Now().In(loc))
+	from := to.AddDate(0, 0, -(days - 1))
+
+	cal, err := buildCalendar(userID, from, to, loc)
+	if err != nil {
+		c.String(500, "查询数据失败")
+		return
+	}
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Status(200)
+	if err := ics.Write(c.Writer, cal); err != nil {
+		utils.GetLogger().Warn("输出订阅日历失败", zap.String("user_id", userID), zap.Error(err))
+	}
+}
+
+// GetFeedToken 查询当前是否已开启日历订阅
+func GetFeedToken(c *gin.Context) {
+	var rows []models.FeedToken
+	_, err := utils.Client.From("feed_tokens").
+		Select("user_id,created_at", "", false).
+		Eq("user_id", c.GetString("user_id")).
+		ExecuteTo(&rows)
+	if err != nil {
+		utils.Error(c, 500, "查询订阅失败")
+		return
+	}
+	if len(rows) == 0 {
+		utils.Success(c, models.FeedTokenStatus{})
+		return
+	}
+	utils.Success(c, models.FeedTokenStatus{Active: true, CreatedAt: rows[0].CreatedAt})
+}
+
+// CreateFeedToken 生成新的订阅令牌，旧令牌 (如有) 立即失效
+//
+// 令牌只在此处返回一次，服务端仅保存其摘要。
+func CreateFeedToken(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		utils.Error(c, 500, "生成订阅令牌失败")
+		return
+	}
+	token := hex.EncodeToString(buf)
+
+	row := models.FeedToken{
+		UserID:    userID,
+		TokenHash: hashFeedToken(token),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	var result []models.FeedToken
+	_, err := utils.Client.From("feed_tokens").Upsert(row, "user_id", "", "").ExecuteTo(&result)
+	if err != nil {
+		utils.GetLogger().Error("保存订阅令牌失败", zap.String("user_id", userID), zap.Error(err))
+		utils.Error(c, 500, "生成订阅令牌失败")
+		return
+	}
+	utils.Success(c, models.FeedTokenResponse{
+		Token:     token,
+		FeedPath:  "/feed/" + token + ".ics",
+		CreatedAt: row.CreatedAt,
+	})
+}
+
+// RevokeFeedToken 吊销订阅令牌，已订阅的日历将无法再更新
+func RevokeFeedToken(c *gin.Context) {
+	_, _, err := utils.Client.From("feed_tokens").
+		Delete("", "").
+		Eq("user_id", c.GetString("user_id")).
+		Execute()
+	if err != nil {
+		utils.Error(c, 500, "吊销订阅失败")
+		return
+	}
+	utils.Success(c, "已吊销")
+}
+
+// feedTokenOwner 查找令牌所属用户，令牌无效时返回空字符串
+func feedTokenOwner(token string) (string, error) {
+	if len(token) != 64 {
+		return "", nil
+	}
+	if _, err := hex.DecodeString(token); err != nil {
+		return "", nil
+	}
+	var rows []models.FeedToken
+	_, err := utils.Client.From("feed_tokens").
+		Select("user_id", "", false).
+		Eq("token_hash", hashFeedToken(token)).
+		ExecuteTo(&rows)
+	if err != nil || len(rows) == 0 {
+		return "", err
+	}
+	return rows[0].UserID, nil
+}
+
+// hashFeedToken 令牌的 SHA-256 摘要 (十六进制)
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// buildCalendar 读取范围内的记录并转换为日历事件
+func buildCalendar(userID string, from, to time.Time, loc *time.Location) (ics.Calendar, error) {
+	cal := ics.Calendar{Name: "每日记录"}
+	err := fetchRecordsPaged(userID, from, to, loc, func(page []models.Record) error {
+		for _, r := range page {
+			if e, ok := recordEvent(r); ok {
+				cal.Events = append(cal.Events, e)
+			}
+		}
+		return nil
+	})
+	return cal, err
+}
+
+// recordEvent 将记录转换为日历事件，标签作为分类
+//
+// 有开始时间时从开始时间起算时长；否则视为在创建时刚刚结束。
+func recordEvent(r models.Record) (ics.Event, bool) {
+	created, ok := utils.ParseTimestamp(r.CreatedAt)
+	if !ok {
+		return ics.Event{}, false
+	}
+	duration := time.Duration(r.Duration) * time.Minute
+	start, end := created.Add(-duration), created
+	if r.StartedAt != "" {
+		if t, ok := utils.ParseTimestamp(r.StartedAt); ok {
+			start, end = t, t.Add(duration)
+		}
+	}
+	return ics.Event{
+		UID:         r.ID + "@daily-records",
+		Start:       start,
+		End:         end,
+		Summary:     r.Content,
+		Description: r.Tag + " · " + strconv.Itoa(r.Duration) + " 分钟",
+		Categories:  []string{r.Tag},
+		Created:     created,
+	}, true
+}
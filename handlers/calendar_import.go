@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/user/daily-records-backend/ics"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+const (
+	// calendarMaxRules 标签映射规则的最大条数
+	calendarMaxRules = 50
+	// externalIDChunk 查询已导入事件时每次请求的标识数 (受 URL 长度限制)
+	externalIDChunk = 100
+	// calendarUntitled 无标题事件的行动描述
+	calendarUntitled = "日历事件"
+)
+
+// ImportCalendar 从上传的 .ics 文件导入日历事件为记录
+//
+// multipart 表单字段:
+//   - file: .ics 文件
+//   - rules: 标签映射规则 (JSON 数组，见 models.CalendarTagRule)，按顺序匹配
+//   - default_tag: 未命中规则时的标签，默认 "其他"
+//   - timezone: 不带时区的浮动时间按此时区解析，默认使用 DEFAULT_TIMEZONE 或 UTC
+//   - from、to: 只导入开始日期在该范围内的事件 (文件含重复事件时必填)
+//   - dry_run、skip_invalid: 同 ImportRecords
+//
+// 时长取 DTEND 与 DTSTART 之差，描述取 SUMMARY (超过 50 字截断)。全天事件、已取消的事件
+// 以及按事件 UID 判断已导入过的事件会被跳过，因此同一日历可以反复导入。
+// 重复事件 (RRULE) 按 ics.Expand 展开 from 到 to 之间的发生，每个发生单独去重；
+// 规则不受支持或未指定范围时该事件计入错误。Total 为展开后的事件数。
+func ImportCalendar(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ValidationError(c, "请上传日历文件 (file)")
+		return
+	}
+	if fileHeader.Size > importMaxSize {
+		utils.ValidationError(c, fmt.Sprintf("导入文件不能超过 %d MB", importMaxSize>>20))
+		return
+	}
+
+	var rules []models.CalendarTagRule
+	if s := c.PostForm("rules"); s != "" {
+		if err := json.Unmarshal([]byte(s), &rules); err != nil || len(rules) > calendarMaxRules {
+			utils.ValidationError(c, fmt.Sprintf("rules 需为 JSON 数组且不超过 %d 条", calendarMaxRules))
+			return
+		}
+		for _, rule := range rules {
+			if err := binding.Validator.ValidateStruct(&rule); err != nil {
+				utils.ValidationError(c, "每条规则需指定 contains 与 tag，field 仅支持 summary、description、categories")
+				return
+			}
+		}
+	}
+	loc, err := utils.LoadLocation(c.PostForm("timezone"))
+	if err != nil {
+		utils.ValidationError(c, "timezone 时区不正确")
+		return
+	}
+
+	var from, to time.Time
+	if c.PostForm("from") != "" || c.PostForm("to") != "" {
+		if from, to, err = utils.ParseDateRange(c.PostForm("from"), c.PostForm("to"), maxRangeDays); err != nil {
+			utils.ValidationError(c, "from 和 to 格式不正确 (格式: 2026-02-16，跨度不超过两年)")
+			return
+		}
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		utils.Error(c, 500, "读取导入文件失败")
+		return
+	}
+	defer f.Close()
+
+	cal, err := ics.Parse(f, loc)
+	if err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+	if len(cal.Events) > importMaxRows {
+		utils.ValidationError(c, fmt.Sprintf("单次最多导入 %d 个事件", importMaxRows))
+		return
+	}
+
+	dryRun := c.PostForm("dry_run") == "true"
+	result := models.ImportResult{
+		DryRun:  dryRun,
+		Errors:  make([]models.ImportRowError, 0),
+		Preview: make([]models.Record, 0),
+	}
+
+	events, rows := expandCalendar(cal.Events, from, to, loc, &result)
+	if len(events) > importMaxRows {
+		utils.ValidationError(c, fmt.Sprintf("重复事件展开后超过 %d 个，请缩小 from 和 to 的范围", importMaxRows))
+		return
+	}
+	result.Total = len(events) + len(result.Errors)
+
+	candidates := make([]models.Record, 0, len(events))
+	seen := make(map[string]bool)
+	for j, e := range events {
+		i := rows[j]
+		day := utils.DateOf(e.Start.In(loc))
+		if e.AllDay || e.Status == "CANCELLED" || !from.IsZero() && (day.Before(from) || day.After(to)) {
+			result.Skipped++
+			continue
+		}
+		record := calendarRecord(e, rules, c.PostForm("default_tag"))
+		if seen[record.ExternalID] {
+			result.Skipped++
+			continue
+		}
+		seen[record.ExternalID] = true
+
+		if e.End.Before(e.Start) {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: i + 1, Message: "结束时间早于开始时间"})
+			continue
+		}
+		if err := prepareRecord(userID, &record); err != nil {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: i + 1, Message: "行动描述不能为空且长度不超过50字，时长需为非负数"})
+			continue
+		}
+		candidates = append(candidates, record)
+	}
+
+	imported, err := importedExternalIDs(userID, candidates)
+	if err != nil {
+		utils.Error(c, 500, "查询已导入事件失败")
+		return
+	}
+	valid := make([]models.Record, 0, len(candidates))
+	for _, record := range candidates {
+		if imported[record.ExternalID] {
+			result.Skipped++
+			continue
+		}
+		valid = append(valid, record)
+		if len(result.Preview) < importPreviewSize {
+			result.Preview = append(result.Preview, record)
+		}
+	}
+	result.Valid = len(valid)
+
+	if dryRun {
+		utils.Success(c, result)
+		return
+	}
+	if len(result.Errors) > 0 && c.PostForm("skip_invalid") != "true" {
+		importResponse(c, 422, fmt.Sprintf("%d 个事件校验失败，未导入任何记录", len(result.Errors)), result)
+		return
+	}
+	commitImport(c, userID, valid, result)
+}
+
+// expandCalendar 展开重复事件，返回事件及其在文件中的序号 (从 0 开始)
+//
+// 单独修改过的实例 (带 RECURRENCE-ID 的事件) 以文件中的版本为准，不再由重复规则生成；
+// 无法展开的重复事件计入 result.Errors。
+func expandCalendar(events []ics.Event, from, to time.Time, loc *time.Location, result *models.ImportResult) ([]ics.Event, []int) {
+	overridden := make(map[string]bool)
+	for _, e := range events {
+		if e.RecurrenceID != "" {
+			overridden[e.UID+"#"+e.RecurrenceID] = true
+		}
+	}
+
+	expanded := make([]ics.Event, 0, len(events))
+	rows := make([]int, 0, len(events))
+	for i, e := range events {
+		if e.RRule == "" || e.AllDay || e.Status == "CANCELLED" {
+			expanded = append(expanded, e)
+			rows = append(rows, i)
+			continue
+		}
+		if from.IsZero() {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: i + 1, Message: "重复事件需指定 from 和 to 以展开"})
+			continue
+		}
+		start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+		end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+		occurrences, err := ics.Expand(e, start, end)
+		if err != nil {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: i + 1, Message: "无法展开重复事件: " + err.Error()})
+			continue
+		}
+		for _, o := range occurrences {
+			if overridden[o.UID+"#"+o.RecurrenceID] {
+				continue
+			}
+			expanded = append(expanded, o)
+			rows = append(rows, i)
+		}
+	}
+	return expanded, rows
+}
+
+// calendarRecord 将日历事件转换为记录 (尚未校验)
+func calendarRecord(e ics.Event, rules []models.CalendarTagRule, defaultTag string) models.Record {
+	content := strings.Join(strings.Fields(e.Summary), " ")
+	if content == "" {
+		content = calendarUntitled
+	}
+	if runes := []rune(content); len(runes) > 50 {
+		content = string(runes[:50])
+	}
+
+	tag := defaultTag
+	if matched, ok := matchCalendarRule(e, rules); ok {
+		tag = matched
+	}
+	if tag == "" {
+		tag = "其他"
+	}
+
+	return models.Record{
+		Content:    content,
+		Tag:        tag,
+		Duration:   int(math.Round(e.End.Sub(e.Start).Minutes())),
+		StartedAt:  e.Start.UTC().Format(time.RFC3339),
+		CreatedAt:  e.End.UTC().Format(time.RFC3339),
+		ExternalID: calendarExternalID(e),
+	}
+}
+
+// matchCalendarRule 返回第一条命中规则的标签
+func matchCalendarRule(e ics.Event, rules []models.CalendarTagRule) (string, bool) {
+	for _, rule := range rules {
+		var text string
+		switch rule.Field {
+		case "description":
+			text = e.Description
+		case "categories":
+			text = strings.Join(e.Categories, ",")
+		default:
+			text = e.Summary
+		}
+		if strings.Contains(strings.ToLower(text), strings.ToLower(rule.Contains)) {
+			return rule.Tag, true
+		}
+	}
+	return "", false
+}
+
+// calendarExternalID 事件的去重标识: UID (重复事件的单独实例附加 RECURRENCE-ID)，
+// 缺少 UID 时以开始时间和标题的摘要代替
+func calendarExternalID(e ics.Event) string {
+	uid := e.UID
+	if uid == "" {
+		sum := sha256.Sum256([]byte(e.Start.UTC().Format(time.RFC3339) + "\n" + e.Summary))
+		uid = hex.EncodeToString(sum[:16])
+	}
+	if e.RecurrenceID != "" {
+		uid += "#" + e.RecurrenceID
+	}
+	return "ics:" + uid
+}
+
+// importedExternalIDs 查询哪些记录的外部标识已经导入过
+func importedExternalIDs(userID string, records []models.Record) (map[string]bool, error) {
+	imported := make(map[string]bool)
+	for start := 0; start < len(records); start += externalIDChunk {
+		end := start + externalIDChunk
+		if end > len(records) {
+			end = len(records)
+		}
+		ids := make([]string, 0, end-start)
+		for _, r := range records[start:end] {
+			ids = append(ids, r.ExternalID)
+		}
+
+		var rows []models.Record
+		_, err := utils.Client.From("daily_records").
+			Select("external_id", "", false).
+			Eq("user_id", userID).
+			In("external_id", ids).
+			ExecuteTo(&rows)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			imported[r.ExternalID] = true
+		}
+	}
+	return imported, nil
+}
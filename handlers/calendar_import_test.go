@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/daily-records-backend/ics"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+func TestExpandCalendar(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	events := []ics.Event{
+		{UID: "standup", Start: start, End: start.Add(15 * time.Minute), Summary: "站会", RRule: "FREQ=DAILY;COUNT=5"},
+		{UID: "standup", Start: start.AddDate(0, 0, 2).Add(time.Hour), End: start.AddDate(0, 0, 2).Add(75 * time.Minute),
+			Summary: "站会 (改期)", RecurrenceID: start.AddDate(0, 0, 2).Format("20060102T150405Z")},
+		{UID: "once", Start: start, End: start.Add(time.Hour), Summary: "评审"},
+		{UID: "monthly", Start: start, End: start.Add(time.Hour), Summary: "月会", RRule: "FREQ=MONTHLY;BYSETPOS=1"},
+	}
+	from, _ := utils.ParseDate("2026-03-01")
+	to, _ := utils.ParseDate("2026-03-31")
+
+	var result models.ImportResult
+	got, rows := expandCalendar(events, from, to, time.UTC, &result)
+
+	var ids []string
+	for _, e := range got {
+		ids = append(ids, calendarExternalID(e))
+	}
+	want := []string{
+		"ics:standup#20260302T090000Z",
+		"ics:standup#20260303T090000Z",
+		"ics:standup#20260305T090000Z",
+		"ics:standup#20260306T090000Z",
+		"ics:standup#20260304T090000Z",
+		"ics:once",
+	}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	if rows[0] != 0 || rows[4] != 1 || rows[5] != 2 {
+		t.Errorf("rows = %v", rows)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Row != 4 || !strings.Contains(result.Errors[0].Message, "BYSETPOS") {
+		t.Errorf("Errors = %+v", result.Errors)
+	}
+
+	// 未指定范围时重复事件报错，不再只导入首次发生
+	result = models.ImportResult{}
+	got, _ = expandCalendar(events, time.Time{}, time.Time{}, time.UTC, &result)
+	if len(got) != 2 || len(result.Errors) != 2 || result.Errors[0].Message != "重复事件需指定 from 和 to 以展开" {
+		t.Errorf("got %d events, Errors = %+v", len(got), result.Errors)
+	}
+}
+
+func TestCalendarRecord(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	rules := []models.CalendarTagRule{
+		{Field: "categories", Contains: "study", Tag: "学习"},
+		{Contains: "会", Tag: "工作"},
+	}
+	tests := []struct {
+		event   ics.Event
+		content string
+		tag     string
+	}{
+		{ics.Event{UID: "a", Summary: "  周会\n复盘 ", Start: start, End: start.Add(50 * time.Minute)}, "周会 复盘", "工作"},
+		{ics.Event{UID: "b", Summary: "阅读", Categories: []string{"Study"}, Start: start, End: start.Add(time.Hour)}, "阅读", "学习"},
+		{ics.Event{UID: "c", Start: start, End: start.Add(time.Hour)}, calendarUntitled, "休闲"},
+		{ics.Event{UID: "d", Summary: strings.Repeat("长", 60), Start: start, End: start}, strings.Repeat("长", 50), "休闲"},
+	}
+	for _, tt := range tests {
+		r := calendarRecord(tt.event, rules, "休闲")
+		if r.Content != tt.content || r.Tag != tt.tag {
+			t.Errorf("calendarRecord(%s) = %q / %q, want %q / %q", tt.event.UID, r.Content, r.Tag, tt.content, tt.tag)
+		}
+		if r.StartedAt != "2026-03-02T09:00:00Z" || r.ExternalID != "ics:"+tt.event.UID {
+			t.Errorf("calendarRecord(%s) = %+v", tt.event.UID, r)
+		}
+	}
+
+	if r := calendarRecord(tests[0].event, nil, ""); r.Tag != "其他" || r.Duration != 50 || r.CreatedAt != "2026-03-02T09:50:00Z" {
+		t.Errorf("无规则时 = %+v", r)
+	}
+}
@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/chart"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+	"golang.org/x/image/font/opentype"
+)
+
+var (
+	chartFontOnce sync.Once
+	chartFont     *opentype.Font
+)
+
+// loadChartFont 加载 PNG 图表使用的字体 (与 PDF 报告相同)，找不到时返回 nil
+func loadChartFont() *opentype.Font {
+	chartFontOnce.Do(func() {
+		for _, p := range fontPaths() {
+			data, err := os.ReadFile(p)
+			if err != nil {
+				continue
+			}
+			coll, err := opentype.ParseCollection(data)
+			if err == nil && coll.NumFonts() > 0 {
+				chartFont, err = coll.Font(0)
+			}
+			if err == nil {
+				return
+			}
+			utils.GetLogger().Warn("加载图表字体失败", zap.String("path", p), zap.Error(err))
+		}
+	})
+	return chartFont
+}
+
+// chartOptions 图表通用参数
+type chartOptions struct {
+	format string
+	theme  chart.Theme
+	width  int
+	height int
+}
+
+// parseChartOptions 解析 format (svg/png)、theme (light/dark)、width、height 参数
+func parseChartOptions(c *gin.Context, defWidth, defHeight int) (chartOptions, bool) {
+	opts := chartOptions{format: c.DefaultQuery("format", "svg")}
+	if opts.format != "svg" && opts.format != "png" {
+		utils.ValidationError(c, "format 仅支持 svg、png")
+		return opts, false
+	}
+	theme, ok := chart.Themes[c.DefaultQuery("theme", "light")]
+	if !ok {
+		utils.ValidationError(c, "theme 仅支持 light、dark")
+		return opts, false
+	}
+	opts.theme = theme
+
+	width, ok1 := queryInt(c, "width", defWidth, chart.MinSize, chart.MaxSize)
+	height, ok2 := queryInt(c, "height", defHeight, chart.MinSize, chart.MaxSize)
+	if !ok1 || !ok2 {
+		utils.ValidationError(c, fmt.Sprintf("width、height 需在 %d-%d 之间", chart.MinSize, chart.MaxSize))
+		return opts, false
+	}
+	opts.width, opts.height = width, height
+	return opts, true
+}
+
+// renderChart 按参数输出 SVG 或 PNG
+func renderChart(c *gin.Context, ch chart.Chart, opts chartOptions) {
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(200)
+	var err error
+	if opts.format == "png" {
+		c.Header("Content-Type", "image/png")
+		err = chart.PNG(c.Writer, ch, opts.width, opts.height, opts.theme, loadChartFont())
+	} else {
+		c.Header("Content-Type", "image/svg+xml; charset=utf-8")
+		err = chart.SVG(c.Writer, ch, opts.width, opts.height, opts.theme)
+	}
+	if err != nil {
+		utils.GetLogger().Error("绘制图表失败", zap.String("user_id", c.GetString("user_id")), zap.Error(err))
+		c.Abort()
+	}
+}
+
+// chartYear 读取 year 参数 (默认今年)
+func chartYear(c *gin.Context) (int, bool) {
+	yearStr := c.Query("year")
+	if yearStr == "" {
+		yearStr = strconv.Itoa(time.Now().Year())
+	}
+	year, err := utils.ParseYear(yearStr)
+	if err != nil {
+		utils.ValidationError(c, "year 格式不正确")
+		return 0, false
+	}
+	return year, true
+}
+
+// GetTrendChart 年度月度时长趋势图 (数据同 GetYearlyStats 的 monthly_trend)
+func GetTrendChart(c *gin.Context) {
+	userID := c.GetString("user_id")
+	year, ok := chartYear(c)
+	if !ok {
+		return
+	}
+	opts, ok := parseChartOptions(c, 640, 320)
+	if !ok {
+		return
+	}
+
+	q := yearQuery(year)
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取年度数据失败")
+		return
+	}
+	q.Granularity = aggregate.Month
+	q.FillEmpty = true
+
+	ch := chart.LineChart{Title: fmt.Sprintf("%d 年每月时长 (小时)", year)}
+	for _, b := range aggregate.Run(rows, q).Buckets {
+		ch.Labels = append(ch.Labels, fmt.Sprintf("%d月", b.Start.Month()))
+		ch.Values = append(ch.Values, b.Hours())
+	}
+	renderChart(c, ch, opts)
+}
+
+// GetTagPieChart 年度标签占比饼图 (数据同 GetYearStat 的 tag_stats)
+func GetTagPieChart(c *gin.Context) {
+	userID := c.GetString("user_id")
+	year, ok := chartYear(c)
+	if !ok {
+		return
+	}
+	opts, ok := parseChartOptions(c, 480, 320)
+	if !ok {
+		return
+	}
+
+	q := yearQuery(year)
+	q.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "查询全年数据失败")
+		return
+	}
+	res := aggregate.Run(rows, q)
+	aggregate.SortByMinutes(res.Buckets)
+
+	ch := chart.PieChart{Title: fmt.Sprintf("%d 年标签占比", year)}
+	for _, b := range res.Buckets {
+		ch.Labels = append(ch.Labels, b.Tag)
+		ch.Values = append(ch.Values, float64(b.Minutes))
+	}
+	renderChart(c, ch, opts)
+}
+
+// GetHeatmapChart 年度每日活跃热力图 (数据同 GetHeatmap，可按标签筛选)
+func GetHeatmapChart(c *gin.Context) {
+	userID := c.GetString("user_id")
+	year, ok := chartYear(c)
+	if !ok {
+		return
+	}
+	opts, ok := parseChartOptions(c, 800, 160)
+	if !ok {
+		return
+	}
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return
+	}
+
+	q := yearQuery(year)
+	q.Granularity = aggregate.Day
+	q.Tag = c.Query("tag")
+	q.FillEmpty = true
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取热力图数据失败")
+		return
+	}
+	buckets := aggregate.Run(rows, q).Buckets
+
+	maxMinutes := 0
+	for _, b := range buckets {
+		if b.Minutes > maxMinutes {
+			maxMinutes = b.Minutes
+		}
+	}
+
+	title := fmt.Sprintf("%d 年活跃度", year)
+	if q.Tag != "" {
+		title = fmt.Sprintf("%d 年活跃度 · %s", year, q.Tag)
+	}
+	ch := chart.HeatmapChart{
+		Title:       title,
+		Start:       q.From,
+		Levels:      make([]int, 0, len(buckets)),
+		Minutes:     make([]int, 0, len(buckets)),
+		SundayFirst: settings.SundayFirst(),
+	}
+	for _, b := range buckets {
+		ch.Levels = append(ch.Levels, heatmapLevel(models.HeatmapDay{Minutes: b.Minutes, Count: b.Count}, maxMinutes))
+		ch.Minutes = append(ch.Minutes, b.Minutes)
+	}
+	renderChart(c, ch, opts)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/chart"
+)
+
+func TestParseChartOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		query   string
+		ok      bool
+		format  string
+		width   int
+		height  int
+		wantMsg string
+	}{
+		{"", true, "svg", 640, 320, ""},
+		{"format=png&theme=dark&width=120&height=2000", true, "png", 120, 2000, ""},
+		{"format=gif", false, "", 0, 0, "format"},
+		{"theme=blue", false, "", 0, 0, "theme"},
+		{"width=119", false, "", 0, 0, "width"},
+		{"height=2001", false, "", 0, 0, "height"},
+		{"width=-1", false, "", 0, 0, "width"},
+		{"width=abc", false, "", 0, 0, "width"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest("GET", "/api/charts/trend?"+tt.query, nil)
+
+		opts, ok := parseChartOptions(c, 640, 320)
+		if ok != tt.ok {
+			t.Errorf("%q: ok = %v, want %v", tt.query, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			if body := w.Body.String(); !strings.Contains(body, `"code":400`) || !strings.Contains(body, tt.wantMsg) {
+				t.Errorf("%q: 响应 = %s", tt.query, body)
+			}
+			continue
+		}
+		if opts.format != tt.format || opts.width != tt.width || opts.height != tt.height {
+			t.Errorf("%q: opts = %+v", tt.query, opts)
+		}
+		if strings.Contains(tt.query, "theme=dark") && opts.theme.Background != chart.Themes["dark"].Background {
+			t.Errorf("%q: 未使用 dark 主题", tt.query)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+// recordPageSize 分页读取原始记录的每页行数 (PostgREST 默认单次最多返回 1000 行)
+const recordPageSize = 1000
+
+// scanRecords 按创建时间顺序分页读取用户的记录，每页交给 fn 处理
+//
+// start、end 为零值时读取全部记录，否则只读取记录时间位于 [start, end) 的记录。
+// 所有需要逐条读取记录的地方 (统计回退、导出、报告等) 都经由这里，避免单次查询被截断。
+func scanRecords(userID string, start, end time.Time, fn func([]models.Record) error) error {
+	for offset := 0; ; offset += recordPageSize {
+		query := utils.Client.From("daily_records").
+			Select("*", "", false).
+			Eq("user_id", userID)
+		if !start.IsZero() || !end.IsZero() {
+			query = query.Or(recordTimeFilter(start, end), "")
+		}
+
+		var page []models.Record
+		_, err := query.
+			Order("created_at", &utils.OrderOptions{Ascending: true}).
+			Order("id", &utils.OrderOptions{Ascending: true}).
+			Range(offset, offset+recordPageSize-1, "").
+			ExecuteTo(&page)
+		if err != nil {
+			return err
+		}
+		if err := fn(page); err != nil {
+			return err
+		}
+		if len(page) < recordPageSize {
+			return nil
+		}
+	}
+}
+
+// fetchRecordsPaged 分页读取 loc 时区下 [from, to] 日期内的记录，每页交给 fn 处理
+func fetchRecordsPaged(userID string, from, to time.Time, loc *time.Location, fn func([]models.Record) error) error {
+	start, end := aggregate.Query{From: from, To: to, Location: loc}.Range()
+	return scanRecords(userID, start, end, fn)
+}
+
+// fetchRecordsBetween 分页读取 loc 时区下闭区间 [from, to] 日期内的全部记录
+func fetchRecordsBetween(userID string, from, to time.Time, loc *time.Location) ([]models.Record, error) {
+	var records []models.Record
+	err := fetchRecordsPaged(userID, from, to, loc, func(page []models.Record) error {
+		records = append(records, page...)
+		return nil
+	})
+	return records, err
+}
+
+// recordTimeFilter PostgREST or 条件: coalesce(started_at, created_at) 位于 [start, end)
+//
+// 与 stats_hourly、daily_rollups 及 aggregate.RecordTime 口径一致，无论数据从哪条路径读取，
+// 范围边缘的记录都归入同一时间段。
+func recordTimeFilter(start, end time.Time) string {
+	s := `"` + start.UTC().Format(time.RFC3339) + `"`
+	e := `"` + end.UTC().Format(time.RFC3339) + `"`
+	return fmt.Sprintf("and(started_at.gte.%s,started_at.lt.%s),and(started_at.is.null,created_at.gte.%s,created_at.lt.%s)", s, e, s, e)
+}
+
+// hourlyRow stats_hourly 数据库函数返回的预聚合行
+type hourlyRow struct {
+	Bucket      string `json:"bucket"`
+	Tag         string `json:"tag"`
+	RecordCount int    `json:"record_count"`
+	Minutes     int    `json:"minutes"`
+}
+
+// rpcRetryAt 数据库聚合失败后暂停调用至该时间 (Unix 秒)，期间直接走内存聚合
+var rpcRetryAt atomic.Int64
+
+// loadRows 加载查询范围内的聚合输入行
+//
+// 默认调用数据库函数 stats_hourly 获取按小时预聚合的数据；
+// 设置 STATS_AGGREGATION=memory 或数据库调用失败时，退回到拉取原始记录在内存中聚合。
+func loadRows(userID string, q aggregate.Query) ([]aggregate.Row, error) {
+	if os.Getenv("STATS_AGGREGATION") != "memory" && time.Now().Unix() >= rpcRetryAt.Load() {
+		rows, err := loadHourlyRows(userID, q)
+		if err == nil {
+			return rows, nil
+		}
+		rpcRetryAt.Store(time.Now().Add(5 * time.Minute).Unix())
+		utils.GetLogger().Warn("数据库聚合失败，改用内存聚合", zap.String("user_id", userID), zap.Error(err))
+	}
+
+	records, err := fetchRecordsBetween(userID, q.From, q.To, q.Location)
+	if err != nil {
+		return nil, err
+	}
+	return aggregate.FromRecords(records), nil
+}
+
+// loadHourlyRows 通过数据库函数获取按小时预聚合的输入行
+func loadHourlyRows(userID string, q aggregate.Query) ([]aggregate.Row, error) {
+	start, end := q.Range()
+	tz := "UTC"
+	if q.Location != nil {
+		tz = q.Location.String()
+	}
+
+	var result []hourlyRow
+	err := utils.CallRPC("stats_hourly", map[string]string{
+		"p_user_id": userID,
+		"p_from":    start.UTC().Format(time.RFC3339),
+		"p_to":      end.UTC().Format(time.RFC3339),
+		"p_tz":      tz,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]aggregate.Row, 0, len(result))
+	for _, r := range result {
+		t, ok := utils.ParseTimestamp(r.Bucket)
+		if !ok {
+			return nil, fmt.Errorf("无法解析聚合时间: %s", r.Bucket)
+		}
+		rows = append(rows, aggregate.Row{Time: t, Tag: r.Tag, Count: r.RecordCount, Minutes: r.Minutes})
+	}
+	return rows, nil
+}
+
+// dailyRollup daily_rollups 汇总表中的一行
+type dailyRollup struct {
+	Day         string `json:"day"`
+	Tag         string `json:"tag"`
+	Minutes     int    `json:"minutes"`
+	RecordCount int    `json:"record_count"`
+}
+
+// rollupPageSize 分页读取汇总表的每页行数 (PostgREST 默认单次最多返回 1000 行)
+const rollupPageSize = 1000
+
+// loadDailyRows 从每日汇总表加载输入行，适用于 UTC 时区、按天及以上粒度的统计
+//
+// 汇总表由数据库触发器随记录增删改同步维护；时区不是 UTC、设置了 STATS_AGGREGATION=memory
+// 或读取失败时退回 loadRows。
+func loadDailyRows(userID string, q aggregate.Query) ([]aggregate.Row, error) {
+	if os.Getenv("STATS_AGGREGATION") == "memory" || (q.Location != nil && q.Location != time.UTC) {
+		return loadRows(userID, q)
+	}
+
+	rows, err := fetchDailyRollups(userID, q)
+	if err != nil {
+		utils.GetLogger().Warn("读取每日汇总失败，改用原始记录", zap.String("user_id", userID), zap.Error(err))
+		return loadRows(userID, q)
+	}
+	return rows, nil
+}
+
+// fetchDailyRollups 分页读取查询范围内的每日汇总
+func fetchDailyRollups(userID string, q aggregate.Query) ([]aggregate.Row, error) {
+	var rows []aggregate.Row
+	for offset := 0; ; offset += rollupPageSize {
+		var page []dailyRollup
+		_, err := utils.Client.From("daily_rollups").
+			Select("day,tag,minutes,record_count", "", false).
+			Eq("user_id", userID).
+			Gte("day", q.From.Format(utils.DateLayout)).
+			Lte("day", q.To.Format(utils.DateLayout)).
+			Order("day", &utils.OrderOptions{Ascending: true}).
+			Range(offset, offset+rollupPageSize-1, "").
+			ExecuteTo(&page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range page {
+			day, err := utils.ParseDate(r.Day)
+			if err != nil {
+				return nil, fmt.Errorf("无法解析汇总日期: %s", r.Day)
+			}
+			rows = append(rows, aggregate.Row{Time: day, Tag: r.Tag, Count: r.RecordCount, Minutes: r.Minutes})
+		}
+		if len(page) < rollupPageSize {
+			return rows, nil
+		}
+	}
+}
+
+// yearQuery 整年范围的查询条件 (UTC)
+func yearQuery(year int) aggregate.Query {
+	return aggregate.Query{
+		From:     time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC),
+		Location: time.UTC,
+	}
+}
+
+// periodStatus 判断日期闭区间 [from, to] 相对 today 是过去、进行中还是未来
+func periodStatus(from, to, today time.Time) string {
+	switch {
+	case today.After(to):
+		return models.PeriodPast
+	case today.Before(from):
+		return models.PeriodFuture
+	}
+	return models.PeriodCurrent
+}
+
+// elapsedDays 返回日期闭区间 [from, to] 中截至 today (含) 已经过去的天数
+func elapsedDays(from, to, today time.Time) int {
+	switch {
+	case today.Before(from):
+		return 0
+	case today.After(to):
+		return utils.DaysBetween(from, to)
+	}
+	return utils.DaysBetween(from, today)
+}
+
+// dailyAverages 计算查询范围内的日均值，分别以已过去天数、有记录的天数和日历天数为分母
+func dailyAverages(rows []aggregate.Row, q aggregate.Query, today time.Time) models.DailyAverages {
+	dayQ := q
+	dayQ.Granularity = aggregate.Day
+	dayQ.GroupBy = nil
+	dayQ.FillEmpty = false
+	res := aggregate.Run(rows, dayQ)
+
+	avg := models.DailyAverages{
+		CalendarDays: utils.DaysBetween(q.From, q.To),
+		ElapsedDays:  elapsedDays(q.From, q.To, today),
+	}
+	for _, b := range res.Buckets {
+		if b.Count > 0 {
+			avg.ActiveDays++
+		}
+	}
+
+	perDay := func(total, days int) float64 {
+		if days == 0 {
+			return 0
+		}
+		return aggregate.Round(float64(total)/float64(days), 2)
+	}
+	avg.RecordsPerElapsedDay = perDay(res.TotalCount, avg.ElapsedDays)
+	avg.RecordsPerActiveDay = perDay(res.TotalCount, avg.ActiveDays)
+	avg.RecordsPerCalendarDay = perDay(res.TotalCount, avg.CalendarDays)
+	avg.MinutesPerElapsedDay = perDay(res.TotalMinutes, avg.ElapsedDays)
+	avg.MinutesPerActiveDay = perDay(res.TotalMinutes, avg.ActiveDays)
+	avg.MinutesPerCalendarDay = perDay(res.TotalMinutes, avg.CalendarDays)
+	return avg
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// GetComparison 两个时间段对比 (本周 vs 上周、本月 vs 去年同月等)
+//
+// 参数: from/to 为当前时间段 (闭区间)，对比时间段二选一:
+//   - compare_from/compare_to 显式指定
+//   - shift=week|month|year|<N>d 将当前时间段整体平移，缺省为紧邻的上一个等长时间段
+func GetComparison(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, err := utils.ParseDateRange(c.Query("from"), c.Query("to"), maxRangeDays)
+	if err != nil {
+		utils.ValidationError(c, "需提供正确的 from 和 to (格式: 2026-02-16，跨度不超过两年)")
+		return
+	}
+
+	var prevFrom, prevTo time.Time
+	if c.Query("compare_from") != "" || c.Query("compare_to") != "" {
+		prevFrom, prevTo, err = utils.ParseDateRange(c.Query("compare_from"), c.Query("compare_to"), maxRangeDays)
+		if err != nil {
+			utils.ValidationError(c, "compare_from 或 compare_to 格式不正确 (跨度不超过两年)")
+			return
+		}
+	} else {
+		var ok bool
+		prevFrom, prevTo, ok = shiftRange(from, to, c.Query("shift"))
+		if !ok {
+			utils.ValidationError(c, "shift 仅支持 week、month、year 或天数 (如 14d)")
+			return
+		}
+	}
+
+	today := utils.Today(time.UTC)
+	cacheKey := utils.GenerateKey(userID, "compare", strings.Join([]string{
+		today.Format(utils.DateLayout), from.Format(utils.DateLayout), to.Format(utils.DateLayout),
+		prevFrom.Format(utils.DateLayout), prevTo.Format(utils.DateLayout),
+	}, "_"))
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	curQ := aggregate.Query{From: from, To: to, GroupBy: []aggregate.GroupBy{aggregate.ByTag}}
+	prevQ := aggregate.Query{From: prevFrom, To: prevTo, GroupBy: curQ.GroupBy}
+
+	curRows, err := loadRows(userID, curQ)
+	if err != nil {
+		utils.Error(c, 500, "获取当前时间段数据失败")
+		return
+	}
+	prevRows, err := loadRows(userID, prevQ)
+	if err != nil {
+		utils.Error(c, 500, "获取对比时间段数据失败")
+		return
+	}
+
+	cur := aggregate.Run(curRows, curQ)
+	prev := aggregate.Run(prevRows, prevQ)
+
+	resp := models.ComparisonResponse{
+		Current: models.PeriodSummary{
+			PeriodStatus: periodStatus(from, to, today),
+			From:         from.Format(utils.DateLayout),
+			To:           to.Format(utils.DateLayout),
+			TotalRecords: cur.TotalCount,
+			TotalMinutes: cur.TotalMinutes,
+		},
+		Previous: models.PeriodSummary{
+			PeriodStatus: periodStatus(prevFrom, prevTo, today),
+			From:         prevFrom.Format(utils.DateLayout),
+			To:           prevTo.Format(utils.DateLayout),
+			TotalRecords: prev.TotalCount,
+			TotalMinutes: prev.TotalMinutes,
+		},
+		DeltaMinutes: cur.TotalMinutes - prev.TotalMinutes,
+		DeltaPercent: deltaPercent(cur.TotalMinutes, prev.TotalMinutes),
+	}
+
+	resp.TagDeltas, resp.NewTags, resp.DroppedTags = tagDeltas(cur, prev)
+
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
+}
+
+// tagDeltas 对比两个按标签分组的聚合结果，返回各标签变化 (按变化幅度降序)、新增标签和消失标签
+func tagDeltas(cur, prev aggregate.Result) ([]models.TagDelta, []string, []string) {
+	newTags, droppedTags := make([]string, 0), make([]string, 0)
+
+	// 合并两个时间段出现过的全部标签
+	deltas := make(map[string]*models.TagDelta)
+	for _, b := range cur.Buckets {
+		deltas[b.Tag] = &models.TagDelta{Tag: b.Tag, CurrentMinutes: b.Minutes, CurrentCount: b.Count}
+	}
+	for _, b := range prev.Buckets {
+		d, ok := deltas[b.Tag]
+		if !ok {
+			d = &models.TagDelta{Tag: b.Tag}
+			deltas[b.Tag] = d
+			droppedTags = append(droppedTags, b.Tag)
+		}
+		d.PreviousMinutes, d.PreviousCount = b.Minutes, b.Count
+	}
+
+	list := make([]models.TagDelta, 0, len(deltas))
+	for _, d := range deltas {
+		if d.PreviousCount == 0 {
+			newTags = append(newTags, d.Tag)
+		}
+		d.DeltaMinutes = d.CurrentMinutes - d.PreviousMinutes
+		d.DeltaPercent = deltaPercent(d.CurrentMinutes, d.PreviousMinutes)
+		list = append(list, *d)
+	}
+
+	// 按变化幅度降序，便于前端直接展示
+	sort.Slice(list, func(i, j int) bool {
+		ai, aj := abs(list[i].DeltaMinutes), abs(list[j].DeltaMinutes)
+		if ai != aj {
+			return ai > aj
+		}
+		return list[i].Tag < list[j].Tag
+	})
+	sort.Strings(newTags)
+	sort.Strings(droppedTags)
+	return list, newTags, droppedTags
+}
+
+// shiftRange 按 shift 参数计算对比时间段
+//
+// month、year 按日历月平移: 日期落到目标月的同一天，目标月没有这一天时取月末；
+// to 为月末时对应目标月的月末，因此整月 (如 3/1-3/31) 对比的也是上一个整月 (2/1-2/28)。
+func shiftRange(from, to time.Time, shift string) (time.Time, time.Time, bool) {
+	switch shift {
+	case "", "period":
+		days := utils.DaysBetween(from, to)
+		return from.AddDate(0, 0, -days), to.AddDate(0, 0, -days), true
+	case "week":
+		return from.AddDate(0, 0, -7), to.AddDate(0, 0, -7), true
+	case "month":
+		return addMonths(from, -1, false), addMonths(to, -1, true), true
+	case "year":
+		return addMonths(from, -12, false), addMonths(to, -12, true), true
+	}
+
+	if strings.HasSuffix(shift, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(shift, "d"))
+		if err == nil && days > 0 {
+			return from.AddDate(0, 0, -days), to.AddDate(0, 0, -days), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// addMonths 将日期平移 n 个日历月，超出目标月天数时取月末；keepEnd 为 true 且原日期为月末时取目标月月末
+func addMonths(t time.Time, n int, keepEnd bool) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > last || keepEnd && t.AddDate(0, 0, 1).Day() == 1 {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// deltaPercent 计算变化百分比 (保留两位小数)，基数为 0 时返回 nil
+func deltaPercent(current, previous int) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	p := math.Round(float64(current-previous)/float64(previous)*10000) / 100
+	return &p
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
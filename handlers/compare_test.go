@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/daily-records-backend/utils"
+)
+
+func TestShiftRange(t *testing.T) {
+	tests := []struct {
+		from, to, shift  string
+		wantFrom, wantTo string
+	}{
+		{"2026-03-01", "2026-03-31", "month", "2026-02-01", "2026-02-28"},
+		{"2028-03-01", "2028-03-31", "month", "2028-02-01", "2028-02-29"},
+		{"2026-02-01", "2026-02-28", "month", "2026-01-01", "2026-01-31"},
+		{"2026-05-31", "2026-05-31", "month", "2026-04-30", "2026-04-30"},
+		{"2026-01-15", "2026-02-14", "month", "2025-12-15", "2026-01-14"},
+		{"2026-03-10", "2026-03-20", "month", "2026-02-10", "2026-02-20"},
+		{"2028-02-29", "2028-02-29", "year", "2027-02-28", "2027-02-28"},
+		{"2028-02-01", "2028-02-29", "year", "2027-02-01", "2027-02-28"},
+		{"2027-02-01", "2027-02-28", "year", "2026-02-01", "2026-02-28"},
+		{"2026-10-12", "2026-10-18", "week", "2026-10-05", "2026-10-11"},
+		{"2026-10-12", "2026-10-18", "", "2026-10-05", "2026-10-11"},
+		{"2026-10-12", "2026-10-18", "14d", "2026-09-28", "2026-10-04"},
+	}
+	for _, tt := range tests {
+		from, _ := utils.ParseDate(tt.from)
+		to, _ := utils.ParseDate(tt.to)
+		gotFrom, gotTo, ok := shiftRange(from, to, tt.shift)
+		if !ok {
+			t.Errorf("shiftRange(%s, %s, %q) 返回 false", tt.from, tt.to, tt.shift)
+			continue
+		}
+		if f, e := gotFrom.Format(utils.DateLayout), gotTo.Format(utils.DateLayout); f != tt.wantFrom || e != tt.wantTo {
+			t.Errorf("shiftRange(%s, %s, %q) = %s ~ %s, want %s ~ %s", tt.from, tt.to, tt.shift, f, e, tt.wantFrom, tt.wantTo)
+		}
+	}
+
+	for _, shift := range []string{"day", "0d", "-3d", "xd"} {
+		if _, _, ok := shiftRange(utils.Today(time.UTC), utils.Today(time.UTC), shift); ok {
+			t.Errorf("shiftRange(%q) 应返回 false", shift)
+		}
+	}
+}
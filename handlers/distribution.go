@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// GetDistribution 获取按星期和小时分布的统计 (打卡图)
+//
+// 参数: from/to 日期闭区间 (缺省为最近 30 天)，tz 为时区 (如 Asia/Shanghai，缺省为用户设置的时区)，
+// 时间优先取 started_at，未填写时取 created_at。
+func GetDistribution(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	loc, ok := requestLocation(c, userID)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseRangeOrDefault(c, loc, 30)
+	if !ok {
+		utils.ValidationError(c, "from 或 to 格式不正确 (跨度不超过两年)")
+		return
+	}
+
+	today := utils.Today(loc)
+	cacheKey := utils.GenerateKey(userID, "distribution",
+		today.Format(utils.DateLayout)+"_"+from.Format(utils.DateLayout)+"_"+to.Format(utils.DateLayout)+"_"+loc.String())
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	q := aggregate.Query{
+		From:     from,
+		To:       to,
+		Location: loc,
+		GroupBy:  []aggregate.GroupBy{aggregate.ByTag, aggregate.ByWeekday, aggregate.ByHour},
+	}
+	rows, err := loadRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取分布数据失败")
+		return
+	}
+
+	resp := models.PunchCardResponse{
+		PeriodStatus: periodStatus(from, to, today),
+		From:         from.Format(utils.DateLayout),
+		To:           to.Format(utils.DateLayout),
+		Timezone:     loc.String(),
+		Tags:         make([]models.TagPunchCard, 0),
+	}
+
+	// 分桶已按标签、星期、小时排序
+	for _, b := range aggregate.Run(rows, q).Buckets {
+		if n := len(resp.Tags); n == 0 || resp.Tags[n-1].Tag != b.Tag {
+			resp.Tags = append(resp.Tags, models.TagPunchCard{Tag: b.Tag})
+		}
+		addToPunchCard(&resp.Overall, b)
+		addToPunchCard(&resp.Tags[len(resp.Tags)-1].PunchCard, b)
+	}
+
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
+}
+
+// addToPunchCard 将一个分桶计入分布矩阵
+func addToPunchCard(card *models.PunchCard, b aggregate.Bucket) {
+	card.Minutes[b.Weekday][b.Hour] += b.Minutes
+	card.Counts[b.Weekday][b.Hour] += b.Count
+	card.ByWeekday[b.Weekday] += b.Minutes
+	card.ByHour[b.Hour] += b.Minutes
+}
+
+// parseRangeOrDefault 解析 from/to 日期参数 (跨度不超过 maxRangeDays)，均未提供时返回截至今天的最近 days 天
+func parseRangeOrDefault(c *gin.Context, loc *time.Location, days int) (time.Time, time.Time, bool) {
+	fromStr, toStr := c.Query("from"), c.Query("to")
+	if fromStr == "" && toStr == "" {
+		to := utils.Today(loc)
+		return to.AddDate(0, 0, -(days - 1)), to, true
+	}
+
+	from, to, err := utils.ParseDateRange(fromStr, toStr, maxRangeDays)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// durationEdges 时长直方图的分界 (分钟)
+var durationEdges = []int{0, 15, 30, 60, 120}
+
+// defaultDeepThreshold 深度专注的默认时长阈值 (分钟)
+const defaultDeepThreshold = 60
+
+// GetDurationStats 获取单次时长分布: 中位数、P90、最大值、直方图及深度专注次数
+//
+// 参数: from/to 日期闭区间 (缺省为最近 30 天)，tz 时区 (缺省为用户设置的时区)，deep_threshold 深度专注阈值 (分钟，默认 60)
+func GetDurationStats(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	loc, ok := requestLocation(c, userID)
+	if !ok {
+		return
+	}
+	from, to, ok := parseRangeOrDefault(c, loc, 30)
+	if !ok || utils.DaysBetween(from, to) > maxRangeDays {
+		utils.ValidationError(c, "from 或 to 格式不正确 (跨度不超过两年)")
+		return
+	}
+	threshold := defaultDeepThreshold
+	if s := c.Query("deep_threshold"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			utils.ValidationError(c, "deep_threshold 需为正整数")
+			return
+		}
+		threshold = n
+	}
+
+	today := utils.Today(loc)
+	cacheKey := utils.GenerateKey(userID, "durations",
+		today.Format(utils.DateLayout)+"_"+from.Format(utils.DateLayout)+"_"+to.Format(utils.DateLayout)+"_"+loc.String()+"_"+strconv.Itoa(threshold))
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	// 需要逐条时长，只能读取原始记录
+	records, err := fetchRecordsBetween(userID, from, to, loc)
+	if err != nil {
+		utils.Error(c, 500, "获取时长数据失败")
+		return
+	}
+
+	all := make([]int, 0, len(records))
+	byTag := make(map[string][]int)
+	for _, r := range records {
+		all = append(all, r.Duration)
+		byTag[r.Tag] = append(byTag[r.Tag], r.Duration)
+	}
+
+	resp := models.DurationStatsResponse{
+		PeriodStatus:  periodStatus(from, to, today),
+		From:          from.Format(utils.DateLayout),
+		To:            to.Format(utils.DateLayout),
+		DeepThreshold: threshold,
+		Overall:       durationStats(all, threshold),
+		Tags:          make([]models.TagDurationStats, 0, len(byTag)),
+	}
+	for tag, durations := range byTag {
+		resp.Tags = append(resp.Tags, models.TagDurationStats{Tag: tag, DurationStats: durationStats(durations, threshold)})
+	}
+	sort.Slice(resp.Tags, func(i, j int) bool {
+		if resp.Tags[i].Count != resp.Tags[j].Count {
+			return resp.Tags[i].Count > resp.Tags[j].Count
+		}
+		return resp.Tags[i].Tag < resp.Tags[j].Tag
+	})
+
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
+}
+
+// durationStats 计算一组时长的分布统计
+func durationStats(durations []int, threshold int) models.DurationStats {
+	stats := models.DurationStats{
+		Count:     len(durations),
+		Histogram: make([]models.DurationBucket, len(durationEdges)),
+	}
+	for i, min := range durationEdges {
+		stats.Histogram[i].Min = min
+		if i+1 < len(durationEdges) {
+			stats.Histogram[i].Max = durationEdges[i+1]
+		}
+	}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sorted := append([]int(nil), durations...)
+	sort.Ints(sorted)
+
+	total := 0
+	for _, d := range sorted {
+		total += d
+		if d >= threshold {
+			stats.DeepCount++
+			stats.DeepMinutes += d
+		}
+		// 落入最后一个下界不大于 d 的分桶
+		i := sort.Search(len(durationEdges), func(i int) bool { return durationEdges[i] > d }) - 1
+		if i < 0 {
+			i = 0
+		}
+		stats.Histogram[i].Count++
+	}
+
+	stats.Mean = aggregate.Round(float64(total)/float64(len(sorted)), 2)
+	stats.Median = aggregate.Round(aggregate.Percentile(sorted, 50), 2)
+	stats.P90 = aggregate.Round(aggregate.Percentile(sorted, 90), 2)
+	stats.Max = sorted[len(sorted)-1]
+	return stats
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+// utf8BOM 写在 CSV 开头，Excel 才能正确识别 UTF-8 编码的中文
+const utf8BOM = "\ufeff"
+
+// recordColumn 记录导出的一列
+type recordColumn struct {
+	Name  string
+	Value func(r models.Record, at time.Time) string
+}
+
+// recordColumns 可导出的记录列 (at 为记录在请求时区下的发生时间)
+var recordColumns = []recordColumn{
+	{"id", func(r models.Record, _ time.Time) string { return r.ID }},
+	{"date", func(_ models.Record, at time.Time) string { return at.Format(utils.DateLayout) }},
+	{"time", func(_ models.Record, at time.Time) string { return at.Format("15:04") }},
+	{"tag", func(r models.Record, _ time.Time) string { return r.Tag }},
+	{"content", func(r models.Record, _ time.Time) string { return r.Content }},
+	{"duration", func(r models.Record, _ time.Time) string { return strconv.Itoa(r.Duration) }},
+	{"hours", func(r models.Record, _ time.Time) string {
+		return strconv.FormatFloat(aggregate.RoundHours(r.Duration), 'f', 2, 64)
+	}},
+	{"started_at", func(r models.Record, _ time.Time) string { return r.StartedAt }},
+	{"created_at", func(r models.Record, _ time.Time) string { return r.CreatedAt }},
+}
+
+// defaultRecordColumns 未指定 columns 时导出的列
+var defaultRecordColumns = []string{"date", "time", "tag", "content", "duration"}
+
+// ExportRecordsCSV 导出时间范围内的原始记录 (CSV)
+//
+// 参数: from、to (必填)、tz、tag、columns (逗号分隔，可选列见 recordColumns)
+func ExportRecordsCSV(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, loc, ok := parseExportRange(c)
+	if !ok {
+		return
+	}
+	columns, err := parseRecordColumns(c.Query("columns"))
+	if err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+	tag := c.Query("tag")
+
+	// 首页读取成功后才写出响应头，之后逐页写出
+	var w *csv.Writer
+	err = fetchRecordsPaged(userID, from, to, loc, func(page []models.Record) error {
+		if w == nil {
+			w = startCSV(c, exportFilename("records", from, to, "csv"))
+			w.Write(recordHeader(columns))
+		}
+		for _, r := range page {
+			if tag != "" && r.Tag != tag {
+				continue
+			}
+			if line, ok := recordLine(r, columns, loc); ok {
+				w.Write(line)
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		if w == nil {
+			utils.Error(c, 500, "导出记录失败")
+			return
+		}
+		// 响应已开始输出，只能记录日志并中断
+		utils.GetLogger().Error("导出记录中断", zap.String("user_id", userID), zap.Error(err))
+		c.Abort()
+	}
+}
+
+// ExportStatsCSV 导出时间范围内的聚合统计 (CSV)
+//
+// 参数与 /stats/query 相同: from、to、tz、granularity、group_by (默认 tag)、metrics、tag、week_start
+func ExportStatsCSV(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, loc, ok := parseExportRange(c)
+	if !ok {
+		return
+	}
+	granularity, ok := aggregate.ParseGranularity(c.Query("granularity"))
+	if !ok {
+		utils.ValidationError(c, "granularity 仅支持 day、week、month、quarter、year")
+		return
+	}
+	groupParam := c.DefaultQuery("group_by", string(aggregate.ByTag))
+	groupBy, ok := aggregate.ParseGroupBy(groupParam)
+	if !ok {
+		utils.ValidationError(c, "group_by 仅支持 tag、weekday、hour")
+		return
+	}
+	metrics, ok := aggregate.ParseMetrics(c.Query("metrics"))
+	if !ok {
+		utils.ValidationError(c, "metrics 仅支持 count、minutes、hours、ratio、avg_minutes")
+		return
+	}
+
+	q := aggregate.Query{
+		From:        from,
+		To:          to,
+		Location:    loc,
+		Granularity: granularity,
+		GroupBy:     groupBy,
+		Tag:         c.Query("tag"),
+		SundayFirst: c.Query("week_start") == "sunday",
+		FillEmpty:   true,
+	}
+	rows, err := loadRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "查询统计数据失败")
+		return
+	}
+	res := aggregate.Run(rows, q)
+
+	var header []string
+	if granularity != aggregate.None {
+		header = append(header, "period", "start")
+	}
+	for _, g := range groupBy {
+		header = append(header, string(g))
+	}
+	for _, m := range metrics {
+		header = append(header, string(m))
+	}
+
+	w := startCSV(c, exportFilename("stats", from, to, "csv"))
+	w.Write(header)
+	for _, b := range res.Buckets {
+		var line []string
+		if granularity != aggregate.None {
+			line = append(line, b.Period, b.Start.Format(utils.DateLayout))
+		}
+		for _, g := range groupBy {
+			switch g {
+			case aggregate.ByTag:
+				line = append(line, csvSafe(b.Tag))
+			case aggregate.ByWeekday:
+				line = append(line, strconv.Itoa(b.Weekday))
+			case aggregate.ByHour:
+				line = append(line, strconv.Itoa(b.Hour))
+			}
+		}
+		for _, m := range metrics {
+			line = append(line, strconv.FormatFloat(res.Value(b, m), 'f', -1, 64))
+		}
+		w.Write(line)
+	}
+	w.Flush()
+}
+
+// recordLine 按列生成一条记录的 CSV 行，发生时间无法解析时返回 false
+func recordLine(r models.Record, columns []recordColumn, loc *time.Location) ([]string, bool) {
+	at, ok := aggregate.RecordTime(r)
+	if !ok {
+		return nil, false
+	}
+	at = at.In(loc)
+	line := make([]string, len(columns))
+	for i, col := range columns {
+		line[i] = csvSafe(col.Value(r, at))
+	}
+	return line, true
+}
+
+// recordHeader 返回列名表头
+func recordHeader(columns []recordColumn) []string {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name
+	}
+	return header
+}
+
+// parseExportRange 解析导出接口的 from、to、tz 参数，失败时已写入错误响应
+func parseExportRange(c *gin.Context) (time.Time, time.Time, *time.Location, bool) {
+	from, to, err := utils.ParseDateRange(c.Query("from"), c.Query("to"), maxRangeDays)
+	if err != nil {
+		utils.ValidationError(c, "需提供正确的 from 和 to (格式: 2026-02-16，跨度不超过两年)")
+		return time.Time{}, time.Time{}, nil, false
+	}
+	loc, err := utils.LoadLocation(c.Query("tz"))
+	if err != nil {
+		utils.ValidationError(c, "tz 时区不正确")
+		return time.Time{}, time.Time{}, nil, false
+	}
+	return from, to, loc, true
+}
+
+// parseRecordColumns 解析逗号分隔的列名，为空时使用默认列
+func parseRecordColumns(s string) ([]recordColumn, error) {
+	names := defaultRecordColumns
+	if strings.TrimSpace(s) != "" {
+		names = strings.Split(s, ",")
+	}
+
+	columns := make([]recordColumn, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, col := range recordColumns {
+			if col.Name == name {
+				columns = append(columns, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("不支持的列: %s", name)
+		}
+	}
+	return columns, nil
+}
+
+// startCSV 写入下载响应头与 BOM，返回写入响应体的 CSV writer
+func startCSV(c *gin.Context, filename string) *csv.Writer {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	setAttachment(c, filename)
+	c.Status(200)
+	c.Writer.WriteString(utf8BOM)
+	return csv.NewWriter(c.Writer)
+}
+
+// setAttachment 设置下载文件名 (同时提供 ASCII 文件名和 RFC 5987 编码的文件名)
+func setAttachment(c *gin.Context, filename string) {
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`,
+		strings.ReplaceAll(filename, `"`, ""), url.PathEscape(filename)))
+}
+
+// exportFilename 生成导出文件名，如 records_2026-01-01_2026-01-31.csv
+func exportFilename(kind string, from, to time.Time, ext string) string {
+	return fmt.Sprintf("%s_%s_%s.%s", kind, from.Format(utils.DateLayout), to.Format(utils.DateLayout), ext)
+}
+
+// csvSafe 防止以 = + - @ 开头的文本在表格软件中被当作公式执行
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// importMaxSize 导入文件的最大字节数
+	importMaxSize = 5 << 20
+	// importMaxRows 单次导入的最大行数
+	importMaxRows = 10000
+	// importBatchSize 每批写入的记录数
+	importBatchSize = 500
+	// importPreviewSize 结果中预览的记录数
+	importPreviewSize = 20
+)
+
+// importTimeLayouts 导入文件中常见的时间格式，不带时区的按映射中的时区解析
+var importTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/1/2 15:04:05",
+	"2006/1/2 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"2006/1/2",
+}
+
+// ImportRecords 从 CSV 或 JSON 文件导入记录 (Toggl、ATracker、表格等)
+//
+// multipart 表单字段:
+//   - file: 导入文件，CSV 需带表头，JSON 为对象数组
+//   - mapping: 列映射 (JSON，见 models.ImportMapping)
+//   - format: csv 或 json，默认按文件扩展名判断
+//   - delimiter: CSV 分隔符，默认逗号，支持 ; 和 tab
+//   - dry_run: 为 true 时只校验并返回每行的错误与转换预览，不写入
+//   - skip_invalid: 为 true 时跳过出错的行导入其余行，否则有任何错误都不写入
+//
+// 每行按 AddRecord 的规则校验，通过后分批写入。
+func ImportRecords(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		utils.ValidationError(c, "请上传导入文件 (file)")
+		return
+	}
+	if fileHeader.Size > importMaxSize {
+		utils.ValidationError(c, fmt.Sprintf("导入文件不能超过 %d MB", importMaxSize>>20))
+		return
+	}
+
+	var mapping models.ImportMapping
+	if err := json.Unmarshal([]byte(c.PostForm("mapping")), &mapping); err != nil {
+		utils.ValidationError(c, "mapping 需为 JSON 格式的列映射")
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&mapping); err != nil || mapping.Duration == "" && mapping.End == "" {
+		utils.ValidationError(c, "mapping 需指定 content、start 以及 duration 或 end 列，duration_unit 仅支持 minutes、hours、seconds、clock")
+		return
+	}
+	loc, err := utils.LoadLocation(mapping.Timezone)
+	if err != nil {
+		utils.ValidationError(c, "mapping.timezone 时区不正确")
+		return
+	}
+
+	format := strings.ToLower(c.PostForm("format"))
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileHeader.Filename)), ".")
+	}
+	if format != "csv" && format != "json" {
+		utils.ValidationError(c, "format 仅支持 csv、json")
+		return
+	}
+	delimiter, err := parseDelimiter(c.PostForm("delimiter"))
+	if err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		utils.Error(c, 500, "读取导入文件失败")
+		return
+	}
+	defer f.Close()
+
+	var rows []map[string]string
+	if format == "csv" {
+		rows, err = readCSVRows(f, delimiter)
+	} else {
+		rows, err = readJSONRows(f)
+	}
+	if err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+
+	dryRun := c.PostForm("dry_run") == "true"
+	result := models.ImportResult{
+		DryRun:  dryRun,
+		Total:   len(rows),
+		Errors:  make([]models.ImportRowError, 0),
+		Preview: make([]models.Record, 0),
+	}
+	valid := make([]models.Record, 0, len(rows))
+	for i, row := range rows {
+		record, err := mapImportRow(row, mapping, loc)
+		if err == nil && prepareRecord(userID, &record) != nil {
+			err = errors.New("行动描述不能为空且长度不超过50字，时长需为非负数")
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, models.ImportRowError{Row: i + 1, Message: err.Error()})
+			continue
+		}
+		valid = append(valid, record)
+		if len(result.Preview) < importPreviewSize {
+			result.Preview = append(result.Preview, record)
+		}
+	}
+	result.Valid = len(valid)
+
+	if dryRun {
+		utils.Success(c, result)
+		return
+	}
+	if len(result.Errors) > 0 && c.PostForm("skip_invalid") != "true" {
+		importResponse(c, 422, fmt.Sprintf("%d 行校验失败，未导入任何记录", len(result.Errors)), result)
+		return
+	}
+
+	commitImport(c, userID, valid, result)
+}
+
+// commitImport 分批写入校验通过的记录并返回导入结果
+func commitImport(c *gin.Context, userID string, records []models.Record, result models.ImportResult) {
+	n, err := insertBatches(records)
+	result.Imported = n
+	if err != nil {
+		utils.GetLogger().Error("导入记录失败", zap.String("user_id", userID), zap.Int("imported", n), zap.Error(err))
+		importResponse(c, 500, fmt.Sprintf("导入中断，已导入 %d 条", n), result)
+		return
+	}
+	utils.Success(c, result)
+}
+
+// insertBatches 分批写入记录，返回已写入的条数
+func insertBatches(records []models.Record) (int, error) {
+	inserted := 0
+	for start := 0; start < len(records); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(records) {
+			end = len(records)
+		}
+		if _, err := insertRecords(records[start:end]); err != nil {
+			return inserted, err
+		}
+		inserted += end - start
+	}
+	return inserted, nil
+}
+
+// importResponse 失败时仍返回导入结果，便于前端展示每行错误与已导入条数
+func importResponse(c *gin.Context, code int, msg string, result models.ImportResult) {
+	utils.GetLogger().Warn("导入未完成", zap.String("user_id", c.GetString("user_id")), zap.Int("code", code), zap.String("msg", msg))
+	c.JSON(200, utils.Response{Code: code, Msg: msg, Data: result})
+}
+
+// parseDelimiter 解析 CSV 分隔符
+func parseDelimiter(s string) (rune, error) {
+	switch s {
+	case "", ",":
+		return ',', nil
+	case ";":
+		return ';', nil
+	case "tab", "\t":
+		return '\t', nil
+	}
+	return 0, errors.New("delimiter 仅支持 , ; tab")
+}
+
+// readCSVRows 读取带表头的 CSV，每行转换为 列名 -> 值
+func readCSVRows(r io.Reader, delimiter rune) ([]map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("CSV 文件为空或格式不正确")
+	}
+	for i, name := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, utf8BOM))
+	}
+
+	var rows []map[string]string
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV 格式不正确: %v", err)
+		}
+		if len(rows) >= importMaxRows {
+			return nil, fmt.Errorf("单次最多导入 %d 行", importMaxRows)
+		}
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			if i < len(fields) {
+				row[name] = fields[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+// readJSONRows 读取 JSON 对象数组，字段值统一转为字符串
+func readJSONRows(r io.Reader) ([]map[string]string, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	var items []map[string]interface{}
+	if err := decoder.Decode(&items); err != nil {
+		return nil, errors.New("JSON 需为对象数组")
+	}
+	if len(items) > importMaxRows {
+		return nil, fmt.Errorf("单次最多导入 %d 行", importMaxRows)
+	}
+
+	rows := make([]map[string]string, len(items))
+	for i, item := range items {
+		row := make(map[string]string, len(item))
+		for key, v := range item {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				row[key] = v
+			case json.Number:
+				row[key] = v.String()
+			case bool:
+				row[key] = strconv.FormatBool(v)
+			default:
+				b, _ := json.Marshal(v)
+				row[key] = string(b)
+			}
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// mapImportRow 按列映射将一行转换为记录 (尚未校验)
+//
+// 记录以开始时间作为 started_at，开始时间加时长作为 created_at。
+func mapImportRow(row map[string]string, m models.ImportMapping, loc *time.Location) (models.Record, error) {
+	value := func(col string) string {
+		if col == "" {
+			return ""
+		}
+		return strings.TrimSpace(row[col])
+	}
+
+	start, err := parseImportTime(value(m.Start), value(m.StartTime), loc)
+	if err != nil {
+		return models.Record{}, fmt.Errorf("开始时间: %v", err)
+	}
+
+	var minutes int
+	if m.Duration != "" {
+		if minutes, err = parseImportDuration(value(m.Duration), m.DurationUnit); err != nil {
+			return models.Record{}, fmt.Errorf("时长: %v", err)
+		}
+	} else {
+		end, err := parseImportTime(value(m.End), value(m.EndTime), loc)
+		if err != nil {
+			return models.Record{}, fmt.Errorf("结束时间: %v", err)
+		}
+		if end.Before(start) {
+			return models.Record{}, errors.New("结束时间早于开始时间")
+		}
+		minutes = int(math.Round(end.Sub(start).Minutes()))
+	}
+
+	tag := value(m.Tag)
+	if mapped, ok := m.TagMap[tag]; ok {
+		tag = mapped
+	}
+	if tag == "" {
+		tag = m.DefaultTag
+	}
+	if tag == "" {
+		tag = "其他"
+	}
+
+	return models.Record{
+		Content:   value(m.Content),
+		Tag:       tag,
+		Duration:  minutes,
+		StartedAt: start.UTC().Format(time.RFC3339),
+		CreatedAt: start.Add(time.Duration(minutes) * time.Minute).UTC().Format(time.RFC3339),
+	}, nil
+}
+
+// parseImportTime 解析日期时间，clock 非空时与 date 拼接 (日期、时间分两列的情况)
+func parseImportTime(date, clock string, loc *time.Location) (time.Time, error) {
+	s := date
+	if clock != "" {
+		s = date + " " + clock
+	}
+	if s == "" {
+		return time.Time{}, errors.New("不能为空")
+	}
+	for _, layout := range importTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法识别 %q", s)
+}
+
+// parseImportDuration 按单位将时长换算为分钟，带冒号的值总是按 h:mm[:ss] 解析
+func parseImportDuration(s, unit string) (int, error) {
+	if s == "" {
+		return 0, errors.New("不能为空")
+	}
+	if unit == models.DurationClock || strings.Contains(s, ":") {
+		parts := strings.Split(s, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("无法识别 %q", s)
+		}
+		seconds := 0
+		for i, p := range parts {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 0 || i > 0 && n >= 60 {
+				return 0, fmt.Errorf("无法识别 %q", s)
+			}
+			seconds += n * []int{3600, 60, 1}[i]
+		}
+		return int(math.Round(float64(seconds) / 60)), nil
+	}
+
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, fmt.Errorf("无法识别 %q", s)
+	}
+	switch unit {
+	case models.DurationHours:
+		v *= 60
+	case models.DurationSeconds:
+		v /= 60
+	}
+	return int(math.Round(v)), nil
+}
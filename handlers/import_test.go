@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/user/daily-records-backend/models"
+)
+
+func TestParseImportDuration(t *testing.T) {
+	tests := []struct {
+		in, unit string
+		want     int
+		ok       bool
+	}{
+		{"90", "", 90, true},
+		{"90", models.DurationMinutes, 90, true},
+		{"1.5", models.DurationHours, 90, true},
+		{"5400", models.DurationSeconds, 90, true},
+		{"01:30:00", models.DurationClock, 90, true},
+		{"1:30", "", 90, true},
+		{"0:00:29", "", 0, true},
+		{"0:00:30", "", 1, true},
+		{"1:60", "", 0, false},
+		{"1:2:3:4", "", 0, false},
+		{"-5", "", 0, false},
+		{"NaN", "", 0, false},
+		{"abc", "", 0, false},
+		{"", "", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseImportDuration(tt.in, tt.unit)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseImportDuration(%q, %q) = %d, %v", tt.in, tt.unit, got, err)
+		}
+	}
+}
+
+func TestParseImportTime(t *testing.T) {
+	shanghai := time.FixedZone("UTC+8", 8*3600)
+	want := time.Date(2026, 2, 16, 9, 30, 0, 0, shanghai)
+	tests := []struct {
+		date, clock string
+		want        time.Time
+	}{
+		{"2026-02-16 09:30", "", want},
+		{"2026-02-16T09:30:00", "", want},
+		{"2026/2/16 9:30", "", want},
+		{"16.02.2026 09:30", "", time.Time{}},
+		{"2026/2/16", "09:30", want},
+		{"2026-02-16T01:30:00Z", "", want},
+		{"2026-02-16", "", time.Date(2026, 2, 16, 0, 0, 0, 0, shanghai)},
+	}
+	for _, tt := range tests {
+		got, err := parseImportTime(tt.date, tt.clock, shanghai)
+		if tt.want.IsZero() {
+			if err == nil {
+				t.Errorf("parseImportTime(%q, %q) = %v, want error", tt.date, tt.clock, got)
+			}
+			continue
+		}
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("parseImportTime(%q, %q) = %v, %v, want %v", tt.date, tt.clock, got, err, tt.want)
+		}
+	}
+	if _, err := parseImportTime("", "", time.UTC); err == nil {
+		t.Error("空值应返回错误")
+	}
+}
+
+func TestMapImportRow(t *testing.T) {
+	m := models.ImportMapping{
+		Content:      "Description",
+		Tag:          "Project",
+		Start:        "Start date",
+		StartTime:    "Start time",
+		Duration:     "Duration",
+		DurationUnit: models.DurationClock,
+		TagMap:       map[string]string{"Client A": "工作"},
+		DefaultTag:   "学习",
+	}
+	tests := []struct {
+		row     map[string]string
+		want    models.Record
+		wantErr string
+	}{
+		{
+			row:  map[string]string{"Description": " 写报告 ", "Project": "Client A", "Start date": "2026-02-16", "Start time": "09:00:00", "Duration": "01:30:00"},
+			want: models.Record{Content: "写报告", Tag: "工作", Duration: 90, StartedAt: "2026-02-16T09:00:00Z", CreatedAt: "2026-02-16T10:30:00Z"},
+		},
+		{
+			row:  map[string]string{"Description": "看书", "Start date": "2026-02-16", "Start time": "21:00", "Duration": "0:45"},
+			want: models.Record{Content: "看书", Tag: "学习", Duration: 45, StartedAt: "2026-02-16T21:00:00Z", CreatedAt: "2026-02-16T21:45:00Z"},
+		},
+		{
+			row:     map[string]string{"Description": "看书", "Start date": "明天", "Duration": "0:45"},
+			wantErr: "开始时间",
+		},
+		{
+			row:     map[string]string{"Description": "看书", "Start date": "2026-02-16", "Duration": "一小时"},
+			wantErr: "时长",
+		},
+	}
+	for i, tt := range tests {
+		got, err := mapImportRow(tt.row, m, time.UTC)
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("%d: err = %v, want prefix %q", i, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%d: got %+v, %v, want %+v", i, got, err, tt.want)
+		}
+	}
+
+	// 以结束时间计算时长
+	m.Duration, m.End, m.EndTime = "", "End date", "End time"
+	row := map[string]string{"Description": "开会", "Start date": "2026-02-16", "Start time": "10:00", "End date": "2026-02-16", "End time": "09:00"}
+	if _, err := mapImportRow(row, m, time.UTC); err == nil || err.Error() != "结束时间早于开始时间" {
+		t.Errorf("err = %v", err)
+	}
+	row["End time"] = "11:20"
+	if got, err := mapImportRow(row, m, time.UTC); err != nil || got.Duration != 80 {
+		t.Errorf("got %+v, %v", got, err)
+	}
+}
+
+func TestPrepareRecord(t *testing.T) {
+	tests := []struct {
+		record models.Record
+		ok     bool
+		tag    string
+	}{
+		{models.Record{Content: "跑步", Tag: "休闲", Duration: 30}, true, "休闲"},
+		{models.Record{Content: "跑步", Tag: "运动", Duration: 30}, true, "其他"},
+		{models.Record{Content: "", Tag: "休闲", Duration: 30}, false, ""},
+		{models.Record{Content: strings.Repeat("跑", 51), Tag: "休闲"}, false, ""},
+		{models.Record{Content: "跑步", Tag: "休闲", Duration: -1}, false, ""},
+		{models.Record{Content: "跑步", Duration: 30}, false, ""},
+	}
+	for i, tt := range tests {
+		r := tt.record
+		err := prepareRecord("user", &r)
+		if (err == nil) != tt.ok {
+			t.Errorf("%d: err = %v, want ok = %v", i, err, tt.ok)
+			continue
+		}
+		if tt.ok && (r.UserID != "user" || r.Tag != tt.tag) {
+			t.Errorf("%d: got %+v", i, r)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/insights"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+// insightUserTTL 超过该时长未请求洞察的用户不再由后台任务刷新
+const insightUserTTL = 7 * 24 * time.Hour
+
+// insightUsers 最近请求过洞察的用户 (user_id -> 最后请求时间)
+var insightUsers sync.Map
+
+// GetInsights 获取当前用户的洞察 (长时间未记录、近期激增或骤降)
+//
+// 结果由后台任务定期计算并缓存，缓存缺失时即时计算。
+func GetInsights(c *gin.Context) {
+	userID := c.GetString("user_id")
+	insightUsers.Store(userID, time.Now())
+
+	if cached := utils.GlobalCache.Get(insightsCacheKey(userID)); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	resp, err := computeInsights(userID)
+	if err != nil {
+		utils.Error(c, 500, "生成洞察失败")
+		return
+	}
+	utils.Success(c, resp)
+}
+
+// StartInsightsJob 启动后台任务，按 interval 为最近活跃的用户重新计算洞察并写入缓存
+func StartInsightsJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			refreshInsights()
+		}
+	}()
+}
+
+// refreshInsights 刷新所有活跃用户的洞察缓存
+func refreshInsights() {
+	insightUsers.Range(func(key, value interface{}) bool {
+		userID := key.(string)
+		if time.Since(value.(time.Time)) > insightUserTTL {
+			insightUsers.Delete(userID)
+			return true
+		}
+		if _, err := computeInsights(userID); err != nil {
+			utils.GetLogger().Warn("刷新洞察失败", zap.String("user_id", userID), zap.Error(err))
+		}
+		return true
+	})
+}
+
+// computeInsights 计算用户的洞察并写入缓存
+func computeInsights(userID string) (models.InsightsResponse, error) {
+	settings, err := loadSettings(userID)
+	if err != nil {
+		return models.InsightsResponse{}, err
+	}
+	loc := settingsLocation(settings)
+
+	today := utils.Today(loc)
+	q := aggregate.Query{
+		From:     today.AddDate(0, 0, -(insights.LookbackDays - 1)),
+		To:       today,
+		Location: loc,
+	}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		return models.InsightsResponse{}, err
+	}
+
+	resp := models.InsightsResponse{
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Insights:    insights.Detect(rows, today, loc),
+	}
+	utils.GlobalCache.Set(insightsCacheKey(userID), resp)
+	return resp, nil
+}
+
+func insightsCacheKey(userID string) string {
+	return utils.GenerateKey(userID, "insights", "")
+}
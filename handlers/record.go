@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/user/daily-records-backend/models"
 	"github.com/user/daily-records-backend/utils"
 )
@@ -11,19 +12,14 @@ import (
 // AddRecord 添加单条记录
 func AddRecord(c *gin.Context) {
 	var record models.Record
-	// 参数绑定与校验
-	if err := c.ShouldBindJSON(&record); err != nil {
+	// 参数绑定与校验 (与导入共用 prepareRecord)
+	if err := c.ShouldBindJSON(&record); err != nil || prepareRecord(c.GetString("user_id"), &record) != nil {
 		utils.ValidationError(c, "行动描述不能为空且长度不超过50字，运动时长需为正数")
 		return
 	}
 
-	userID := c.GetString("user_id")
-	record.UserID = userID
-	record.Tag = models.ValidateTag(record.Tag) // 标签校验与修正
-
 	// 插入 Supabase
-	var result []models.Record
-	_, err := utils.Client.From("daily_records").Insert(record, false, "", "", "").ExecuteTo(&result)
+	result, err := insertRecords([]models.Record{record})
 	if err != nil {
 		utils.Error(c, 500, "保存记录失败: "+err.Error())
 		return
@@ -47,13 +43,12 @@ func BatchAddRecords(c *gin.Context) {
 	successCount := 0
 	var failedList []models.Record
 
+	// 逐条写入，单条失败不影响其余记录
+	// 离线同步保持原有行为，不做字段校验 (只修正标签)，failed_list 中只有写入失败、可以重试的记录
 	for _, req := range body.Records {
 		req.UserID = userID
 		req.Tag = models.ValidateTag(req.Tag)
-
-		var res []models.Record
-		_, err := utils.Client.From("daily_records").Insert(req, false, "", "", "").ExecuteTo(&res)
-		if err != nil {
+		if _, err := insertRecords([]models.Record{req}); err != nil {
 			failedList = append(failedList, req)
 		} else {
 			successCount++
@@ -140,3 +135,65 @@ func DeleteRecord(c *gin.Context) {
 
 	utils.Success(c, "删除成功")
 }
+
+// prepareRecord 按 AddRecord 的规则校验记录并修正标签 (供导入等非 JSON 绑定的来源使用)
+func prepareRecord(userID string, record *models.Record) error {
+	if err := binding.Validator.ValidateStruct(record); err != nil {
+		return err
+	}
+	record.UserID = userID
+	record.Tag = models.ValidateTag(record.Tag)
+	return nil
+}
+
+// insertRecords 写入记录，返回保存后的记录
+//
+// PostgREST 批量写入要求每个对象的字段完全一致，因此按 recordRows 分组后每组一次请求。
+func insertRecords(records []models.Record) ([]models.Record, error) {
+	var saved []models.Record
+	for _, rows := range recordRows(records) {
+		var result []models.Record
+		_, err := utils.Client.From("daily_records").Insert(rows, false, "", "", "").ExecuteTo(&result)
+		if err != nil {
+			return saved, err
+		}
+		saved = append(saved, result...)
+	}
+	return saved, nil
+}
+
+// recordRows 将记录转换为写入行，并按字段集合分组 (保持原有顺序)
+//
+// started_at、external_id 为空时显式写入 null；id、created_at 为空时省略，由数据库生成默认值。
+func recordRows(records []models.Record) [][]map[string]interface{} {
+	var groups [][]map[string]interface{}
+	index := make(map[string]int)
+	for _, r := range records {
+		row := map[string]interface{}{
+			"user_id":     r.UserID,
+			"content":     r.Content,
+			"tag":         r.Tag,
+			"duration":    r.Duration,
+			"started_at":  nullableString(r.StartedAt),
+			"external_id": nullableString(r.ExternalID),
+		}
+		key := ""
+		if r.ID != "" {
+			row["id"] = r.ID
+			key += "id,"
+		}
+		if r.CreatedAt != "" {
+			row["created_at"] = r.CreatedAt
+			key += "created_at,"
+		}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], row)
+	}
+	return groups
+}
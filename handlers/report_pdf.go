@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/pdf"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+// defaultFontPaths 未设置 PDF_FONT_PATH 时依次尝试的中文字体 (Alpine: apk add font-wqy-zenhei)
+var defaultFontPaths = []string{
+	"/usr/share/fonts/wqy-zenhei/wqy-zenhei.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-zenhei.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+}
+
+// fontPaths 候选字体路径，设置了 PDF_FONT_PATH 时只使用该字体
+func fontPaths() []string {
+	if p := os.Getenv("PDF_FONT_PATH"); p != "" {
+		return []string{p}
+	}
+	return defaultFontPaths
+}
+
+var (
+	reportFontOnce sync.Once
+	reportFont     *pdf.Font
+)
+
+// loadReportFont 加载 PDF 报告使用的中文字体，找不到时返回 nil (中文将无法显示)
+func loadReportFont() *pdf.Font {
+	reportFontOnce.Do(func() {
+		for _, p := range fontPaths() {
+			f, err := pdf.LoadFont(p)
+			if err == nil {
+				reportFont = f
+				return
+			}
+			if !os.IsNotExist(err) {
+				utils.GetLogger().Warn("加载 PDF 字体失败", zap.String("path", p), zap.Error(err))
+			}
+		}
+		utils.GetLogger().Warn("未找到中文字体，PDF 报告中的中文将无法显示 (可设置 PDF_FONT_PATH)")
+	})
+	return reportFont
+}
+
+// 报告版式 (单位: 点)
+const (
+	reportMargin     = 48.0
+	reportLineHeight = 16.0
+)
+
+var (
+	reportTextColor  = pdf.Hex("#222222")
+	reportMutedColor = pdf.Hex("#777777")
+	reportRuleColor  = pdf.Hex("#DDDDDD")
+)
+
+// pdfReport 自上而下排版的报告，空间不足时自动换页
+type pdfReport struct {
+	doc  *pdf.Document
+	page *pdf.Page
+	y    float64
+}
+
+func newPDFReport(title string) *pdfReport {
+	doc := pdf.New(loadReportFont())
+	doc.SetTitle(title)
+	r := &pdfReport{doc: doc}
+	r.newPage()
+	r.y += 8
+	r.page.Text(reportMargin, r.y+14, 20, reportTextColor, title)
+	r.y += 34
+	return r
+}
+
+func (r *pdfReport) newPage() {
+	r.page = r.doc.AddPage()
+	r.y = reportMargin
+}
+
+// ensure 当前页剩余高度不足 h 时换页
+func (r *pdfReport) ensure(h float64) {
+	if r.y+h > pdf.PageHeight-reportMargin {
+		r.newPage()
+	}
+}
+
+func (r *pdfReport) heading(s string) {
+	r.ensure(40)
+	r.y += 10
+	r.page.Text(reportMargin, r.y+12, 13, reportTextColor, s)
+	r.y += 16
+	r.page.Line(reportMargin, r.y, pdf.PageWidth-reportMargin, r.y, 0.5, reportRuleColor)
+	r.y += 8
+}
+
+func (r *pdfReport) text(s string) {
+	r.ensure(reportLineHeight)
+	r.page.Text(reportMargin, r.y+11, 10, reportTextColor, s)
+	r.y += reportLineHeight
+}
+
+// table 绘制表格，widths 为各列宽度，超出列宽的文本会被截断
+func (r *pdfReport) table(headers []string, widths []float64, rows [][]string) {
+	row := func(cells []string, color pdf.Color) {
+		r.ensure(reportLineHeight)
+		x := reportMargin
+		for i, cell := range cells {
+			r.page.Text(x, r.y+11, 9, color, r.fit(cell, widths[i]-6, 9))
+			x += widths[i]
+		}
+		r.y += reportLineHeight
+	}
+	row(headers, reportMutedColor)
+	for _, cells := range rows {
+		row(cells, reportTextColor)
+	}
+	r.y += 4
+}
+
+// fit 截断文本使其不超过 width
+func (r *pdfReport) fit(s string, width, size float64) string {
+	if r.doc.TextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && r.doc.TextWidth(string(runes)+"…", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// charts 并排绘制柱状图与饼图
+func (r *pdfReport) charts(barLabels []string, barValues []float64, pieLabels []string, pieValues []float64) {
+	const height = 160.0
+	r.ensure(height + 10)
+	contentWidth := pdf.PageWidth - 2*reportMargin
+	r.page.BarChart(reportMargin, r.y, contentWidth*0.55, height, barLabels, barValues, pdf.Palette[0])
+	pieX := reportMargin + contentWidth*0.55 + 60
+	r.page.PieChart(pieX, r.y+height/2, 55, pieLabels, pieValues, pieX+65)
+	r.y += height + 10
+}
+
+func (r *pdfReport) write(c *gin.Context, filename string) {
+	c.Header("Content-Type", "application/pdf")
+	setAttachment(c, filename)
+	c.Status(200)
+	if err := r.doc.Write(c.Writer); err != nil {
+		utils.GetLogger().Error("写出 PDF 失败", zap.String("user_id", c.GetString("user_id")), zap.Error(err))
+		c.Abort()
+	}
+}
+
+// tagSection 绘制按时长排序的标签统计表，以及时间分布柱状图和标签饼图
+func (r *pdfReport) tagSection(tagRes aggregate.Result, barLabels []string, barValues []float64) {
+	aggregate.SortByMinutes(tagRes.Buckets)
+	rows := make([][]string, 0, len(tagRes.Buckets))
+	labels := make([]string, 0, len(tagRes.Buckets))
+	values := make([]float64, 0, len(tagRes.Buckets))
+	for _, b := range tagRes.Buckets {
+		rows = append(rows, []string{
+			b.Tag,
+			strconv.Itoa(b.Count),
+			fmt.Sprintf("%.1f", b.Hours()),
+			fmt.Sprintf("%.1f%%", tagRes.Ratio(b)*100),
+		})
+		labels = append(labels, b.Tag)
+		values = append(values, float64(b.Minutes))
+	}
+
+	r.heading("标签统计")
+	r.table([]string{"标签", "记录数", "小时", "占比"}, []float64{160, 100, 100, 100}, rows)
+	r.charts(barLabels, barValues, labels, values)
+}
+
+// writeWeekPDF 生成周报 PDF，按天统计与明细时间均为 loc 时区
+func writeWeekPDF(c *gin.Context, start, end time.Time, loc *time.Location, records []models.Record) {
+	title := fmt.Sprintf("周总结 (%s ~ %s)", start.Format(utils.DateLayout), end.Format(utils.DateLayout))
+	r := newPDFReport(title)
+
+	rows := aggregate.FromRecords(records)
+	q := aggregate.Query{From: start, To: end, Location: loc}
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	tagRes := aggregate.Run(rows, tagQ)
+
+	dayQ := q
+	dayQ.Granularity = aggregate.Day
+	dayQ.FillEmpty = true
+	var dayLabels []string
+	var dayHours []float64
+	for _, b := range aggregate.Run(rows, dayQ).Buckets {
+		dayLabels = append(dayLabels, b.Start.Format("01-02"))
+		dayHours = append(dayHours, b.Hours())
+	}
+
+	r.text(fmt.Sprintf("共 %d 条记录，总计用时 %.1f 小时", tagRes.TotalCount, aggregate.RoundHours(tagRes.TotalMinutes)))
+	r.tagSection(tagRes, dayLabels, dayHours)
+
+	r.heading("记录明细")
+	detail := make([][]string, 0, len(records))
+	for _, rec := range records {
+		at, ok := aggregate.RecordTime(rec)
+		if !ok {
+			continue
+		}
+		detail = append(detail, []string{at.In(loc).Format("01-02 15:04"), rec.Tag, rec.Content, strconv.Itoa(rec.Duration)})
+	}
+	r.table([]string{"时间", "标签", "内容", "分钟"}, []float64{80, 70, 300, 50}, detail)
+
+	r.write(c, exportFilename("week", start, end, "pdf"))
+}
+
+// writeYearPDF 生成年度报告 PDF
+func writeYearPDF(c *gin.Context, userID string, year int) {
+	review, err := buildYearReview(userID, year)
+	if err != nil {
+		utils.Error(c, 500, "生成年度报告失败")
+		return
+	}
+	q := yearQuery(year)
+	q.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "生成年度报告失败")
+		return
+	}
+	tagRes := aggregate.Run(rows, q)
+
+	monthQ := yearQuery(year)
+	monthQ.Granularity = aggregate.Month
+	monthQ.FillEmpty = true
+	var monthLabels []string
+	var monthHours []float64
+	for _, b := range aggregate.Run(rows, monthQ).Buckets {
+		monthLabels = append(monthLabels, strconv.Itoa(int(b.Start.Month()))+"月")
+		monthHours = append(monthHours, aggregate.Round(b.Hours(), 0))
+	}
+
+	r := newPDFReport(fmt.Sprintf("%d 年度精进报告", year))
+	r.text(fmt.Sprintf("共 %d 条记录，累计 %.1f 小时，有记录的天数 %d 天", review.TotalRecords, review.TotalHours, review.ActiveDays))
+	if review.LongestStreak.Days > 0 {
+		r.text(fmt.Sprintf("最长连续记录 %d 天 (%s ~ %s)", review.LongestStreak.Days, review.LongestStreak.Start, review.LongestStreak.End))
+	}
+	if review.BusiestMonth != nil {
+		r.text(fmt.Sprintf("最忙的月份 %s (%.1f 小时)", review.BusiestMonth.Period, float64(review.BusiestMonth.Minutes)/60.0))
+	}
+	r.tagSection(tagRes, monthLabels, monthHours)
+
+	if b := review.Balance; b != nil && b.Score != nil {
+		r.heading(fmt.Sprintf("生活平衡评分 %.1f / 100", *b.Score))
+		rows := make([][]string, 0, len(b.Tags))
+		for _, d := range b.Tags {
+			rows = append(rows, []string{d.Tag, fmt.Sprintf("%.1f%%", d.ActualPercent), fmt.Sprintf("%.1f%%", d.TargetPercent)})
+		}
+		r.table([]string{"标签", "实际", "目标"}, []float64{160, 100, 100}, rows)
+	}
+
+	r.write(c, strconv.Itoa(year)+".pdf")
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// 年度回顾中列出的常用标签和常做事项数量
+const (
+	reviewTopTags   = 3
+	reviewFrequentN = 5
+)
+
+// GetYearReview 获取年度回顾
+func GetYearReview(c *gin.Context) {
+	userID := c.GetString("user_id")
+	yearStr := c.Query("year")
+	if yearStr == "" {
+		yearStr = strconv.Itoa(time.Now().Year())
+	}
+	year, err := utils.ParseYear(yearStr)
+	if err != nil {
+		utils.ValidationError(c, "year 格式不正确")
+		return
+	}
+
+	review, err := buildYearReview(userID, year)
+	if err != nil {
+		utils.Error(c, 500, "生成年度回顾失败")
+		return
+	}
+	utils.Success(c, review)
+}
+
+// buildYearReview 汇总年度回顾数据 (结果缓存 1 小时)
+func buildYearReview(userID string, year int) (models.YearReview, error) {
+	// 读取设置失败时不计算平衡评分
+	settings, err := loadSettings(userID)
+	if err != nil {
+		settings = models.DefaultSettings(userID)
+	}
+
+	today := utils.Today(time.UTC)
+	cacheKey := utils.GenerateKey(userID, "review",
+		today.Format(utils.DateLayout)+"_"+strconv.Itoa(year)+"_"+targetsKey(settings.BalanceTargets))
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		return cached.(models.YearReview), nil
+	}
+
+	// 首次记录和常做事项需要内容，读取原始记录
+	q := yearQuery(year)
+	records, err := fetchRecordsBetween(userID, q.From, q.To, time.UTC)
+	if err != nil {
+		return models.YearReview{}, err
+	}
+	rows := aggregate.FromRecords(records)
+
+	prevQ := yearQuery(year - 1)
+	prevQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	prevRows, err := loadDailyRows(userID, prevQ)
+	if err != nil {
+		return models.YearReview{}, err
+	}
+
+	review := models.YearReview{
+		PeriodStatus:       periodStatus(q.From, q.To, today),
+		Year:               year,
+		TopTags:            make([]models.RangeTagStat, 0, reviewTopTags),
+		FrequentActivities: make([]models.ActivityCount, 0, reviewFrequentN),
+	}
+
+	// 总量与常用标签
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	tagRes := aggregate.Run(rows, tagQ)
+	review.TotalRecords = tagRes.TotalCount
+	review.TotalMinutes = tagRes.TotalMinutes
+	review.TotalHours = aggregate.RoundHours(tagRes.TotalMinutes)
+
+	sorted := append([]aggregate.Bucket(nil), tagRes.Buckets...)
+	aggregate.SortByMinutes(sorted)
+	for i, b := range sorted {
+		if i == reviewTopTags {
+			break
+		}
+		review.TopTags = append(review.TopTags, models.RangeTagStat{
+			Tag:     b.Tag,
+			Count:   b.Count,
+			Minutes: b.Minutes,
+			Hours:   b.Hours(),
+			Ratio:   tagRes.Ratio(b),
+		})
+	}
+
+	// 每日: 连续记录、最忙的一天、热力图概要
+	dayQ := q
+	dayQ.Granularity = aggregate.Day
+	dayQ.FillEmpty = true
+	days := aggregate.Run(rows, dayQ).Buckets
+	for _, b := range days {
+		if b.Minutes > review.Heatmap.MaxMinutes {
+			review.Heatmap.MaxMinutes = b.Minutes
+		}
+	}
+	for _, b := range days {
+		if b.Count > 0 {
+			review.ActiveDays++
+		}
+		level := heatmapLevel(models.HeatmapDay{Minutes: b.Minutes, Count: b.Count}, review.Heatmap.MaxMinutes)
+		review.Heatmap.Levels[level]++
+	}
+	review.Heatmap.ActiveDays = review.ActiveDays
+	review.LongestStreak = longestStreak(days)
+	review.BusiestDay = busiestPeriod(days)
+
+	// 最忙的一周、一个月
+	weekQ := q
+	weekQ.Granularity = aggregate.Week
+	review.BusiestWeek = busiestPeriod(aggregate.Run(rows, weekQ).Buckets)
+
+	monthQ := q
+	monthQ.Granularity = aggregate.Month
+	review.BusiestMonth = busiestPeriod(aggregate.Run(rows, monthQ).Buckets)
+
+	// 首次记录与常做事项
+	review.FirstActivity = firstActivity(records)
+	review.FrequentActivities = frequentActivities(records, reviewFrequentN)
+
+	// 与上一年对比
+	prevRes := aggregate.Run(prevRows, prevQ)
+	review.PreviousYear = models.YearOverYear{
+		Year:         year - 1,
+		TotalRecords: prevRes.TotalCount,
+		TotalMinutes: prevRes.TotalMinutes,
+		DeltaMinutes: review.TotalMinutes - prevRes.TotalMinutes,
+		DeltaPercent: deltaPercent(review.TotalMinutes, prevRes.TotalMinutes),
+	}
+	review.PreviousYear.TagDeltas, _, _ = tagDeltas(tagRes, prevRes)
+
+	// 生活平衡 (用户设置了目标分配时)
+	if len(settings.BalanceTargets) > 0 {
+		tagMinutes := make(map[string]int)
+		for _, b := range tagRes.Buckets {
+			tagMinutes[b.Tag] = b.Minutes
+		}
+		balance := balanceScore(tagMinutes, settings.BalanceTargets)
+		balance.Period = strconv.Itoa(year)
+		balance.Start = q.From.Format(utils.DateLayout)
+		review.Balance = &balance
+	}
+
+	utils.GlobalCache.Set(cacheKey, review)
+	return review, nil
+}
+
+// longestStreak 计算按天排列的分桶中最长的连续记录天数
+func longestStreak(days []aggregate.Bucket) models.Streak {
+	var best, cur models.Streak
+	for _, b := range days {
+		if b.Count == 0 {
+			cur = models.Streak{}
+			continue
+		}
+		if cur.Days == 0 {
+			cur.Start = b.Period
+		}
+		cur.Days++
+		cur.End = b.Period
+		if cur.Days > best.Days {
+			best = cur
+		}
+	}
+	return best
+}
+
+// busiestPeriod 返回时长最多的周期，没有任何时长时返回 nil
+func busiestPeriod(buckets []aggregate.Bucket) *models.PeriodTotal {
+	var best *models.PeriodTotal
+	for _, b := range buckets {
+		if b.Minutes > 0 && (best == nil || b.Minutes > best.Minutes) {
+			best = &models.PeriodTotal{Period: b.Period, Count: b.Count, Minutes: b.Minutes}
+		}
+	}
+	return best
+}
+
+// firstActivity 返回时间最早的一条记录
+func firstActivity(records []models.Record) *models.Record {
+	var first *models.Record
+	var firstAt time.Time
+	for i, r := range records {
+		t, ok := aggregate.RecordTime(r)
+		if ok && (first == nil || t.Before(firstAt)) {
+			first, firstAt = &records[i], t
+		}
+	}
+	return first
+}
+
+// frequentActivities 按出现次数返回最常做的 n 件事 (内容与标签均相同视为同一件事)
+func frequentActivities(records []models.Record, n int) []models.ActivityCount {
+	counts := make(map[string]*models.ActivityCount)
+	for _, r := range records {
+		content := strings.TrimSpace(r.Content)
+		key := r.Tag + "\x00" + content
+		if _, ok := counts[key]; !ok {
+			counts[key] = &models.ActivityCount{Content: content, Tag: r.Tag}
+		}
+		counts[key].Count++
+		counts[key].Minutes += r.Duration
+	}
+
+	list := make([]models.ActivityCount, 0, len(counts))
+	for _, a := range counts {
+		list = append(list, *a)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		if list[i].Minutes != list[j].Minutes {
+			return list[i].Minutes > list[j].Minutes
+		}
+		return list[i].Content < list[j].Content
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// renderYearReviewMarkdown 将年度回顾渲染为 Markdown
+func renderYearReviewMarkdown(r models.YearReview) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# 🏆 %d 年度回顾\n\n", r.Year)
+	fmt.Fprintf(&b, "- 记录 **%d** 条，累计 **%.1f** 小时\n", r.TotalRecords, r.TotalHours)
+	fmt.Fprintf(&b, "- 有记录的天数: **%d** 天\n", r.ActiveDays)
+	if r.LongestStreak.Days > 0 {
+		fmt.Fprintf(&b, "- 最长连续记录: **%d** 天 (%s ~ %s)\n", r.LongestStreak.Days, r.LongestStreak.Start, r.LongestStreak.End)
+	}
+
+	if len(r.TopTags) > 0 {
+		b.WriteString("\n## 投入最多的标签\n\n| 标签 | 小时 | 占比 |\n| --- | ---: | ---: |\n")
+		for _, t := range r.TopTags {
+			fmt.Fprintf(&b, "| %s | %.1f | %.1f%% |\n", t.Tag, t.Hours, t.Ratio*100)
+		}
+	}
+
+	b.WriteString("\n## 高光时刻\n\n")
+	for _, p := range []struct {
+		label string
+		total *models.PeriodTotal
+	}{{"最忙的一天", r.BusiestDay}, {"最忙的一周", r.BusiestWeek}, {"最忙的一个月", r.BusiestMonth}} {
+		if p.total != nil {
+			fmt.Fprintf(&b, "- %s: %s (%.1f 小时，%d 条)\n", p.label, p.total.Period, float64(p.total.Minutes)/60.0, p.total.Count)
+		}
+	}
+	fmt.Fprintf(&b, "- 单日最长: %d 分钟，活跃等级分布 (0-4): %v\n", r.Heatmap.MaxMinutes, r.Heatmap.Levels)
+
+	if r.FirstActivity != nil {
+		fmt.Fprintf(&b, "\n## 第一条记录\n\n- %s [%s] %s (%d min)\n", firstDate(r.FirstActivity), r.FirstActivity.Tag, r.FirstActivity.Content, r.FirstActivity.Duration)
+	}
+
+	if len(r.FrequentActivities) > 0 {
+		b.WriteString("\n## 最常做的事\n\n")
+		for i, a := range r.FrequentActivities {
+			fmt.Fprintf(&b, "%d. [%s] %s × %d (%.1f 小时)\n", i+1, a.Tag, a.Content, a.Count, float64(a.Minutes)/60.0)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## 与 %d 年对比\n\n", r.PreviousYear.Year)
+	if r.PreviousYear.DeltaPercent != nil {
+		fmt.Fprintf(&b, "- 总时长 %+.1f 小时 (%+.1f%%)\n", float64(r.PreviousYear.DeltaMinutes)/60.0, *r.PreviousYear.DeltaPercent)
+	} else {
+		fmt.Fprintf(&b, "- 总时长 %+.1f 小时 (上一年没有记录)\n", float64(r.PreviousYear.DeltaMinutes)/60.0)
+	}
+	for _, d := range r.PreviousYear.TagDeltas {
+		fmt.Fprintf(&b, "- %s: %+.1f 小时\n", d.Tag, float64(d.DeltaMinutes)/60.0)
+	}
+
+	if r.Balance != nil && r.Balance.Score != nil {
+		fmt.Fprintf(&b, "\n## 生活平衡\n\n评分 **%.1f** / 100\n\n", *r.Balance.Score)
+		for _, d := range r.Balance.Tags {
+			fmt.Fprintf(&b, "- %s: 实际 %.1f%% / 目标 %.1f%%\n", d.Tag, d.ActualPercent, d.TargetPercent)
+		}
+	}
+
+	return b.String()
+}
+
+// firstDate 返回记录发生的日期
+func firstDate(r *models.Record) string {
+	if t, ok := aggregate.RecordTime(*r); ok {
+		return t.Format(utils.DateLayout)
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// GetSettings 获取当前用户的偏好设置
+func GetSettings(c *gin.Context) {
+	settings, err := loadSettings(c.GetString("user_id"))
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return
+	}
+	utils.Success(c, settings)
+}
+
+// UpdateSettingsRequest 更新设置请求，未提供的字段保持不变
+type UpdateSettingsRequest struct {
+	WeekStart      *string            `json:"week_start"`
+	Timezone       *string            `json:"timezone"`
+	BalanceTargets map[string]float64 `json:"balance_targets"`
+}
+
+// UpdateSettings 更新当前用户的偏好设置
+func UpdateSettings(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationError(c, "请求格式不正确")
+		return
+	}
+
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return
+	}
+	settings.UserID = userID
+
+	if req.WeekStart != nil {
+		if *req.WeekStart != models.WeekStartMonday && *req.WeekStart != models.WeekStartSunday {
+			utils.ValidationError(c, "week_start 仅支持 monday 或 sunday")
+			return
+		}
+		settings.WeekStart = *req.WeekStart
+	}
+	if req.Timezone != nil {
+		if _, err := utils.LoadLocation(*req.Timezone); err != nil {
+			utils.ValidationError(c, "timezone 时区不正确")
+			return
+		}
+		settings.Timezone = *req.Timezone
+	}
+	if req.BalanceTargets != nil {
+		if msg := validateBalanceTargets(req.BalanceTargets); msg != "" {
+			utils.ValidationError(c, msg)
+			return
+		}
+		settings.BalanceTargets = req.BalanceTargets
+	}
+
+	var result []models.UserSettings
+	_, err = utils.Client.From("user_settings").
+		Upsert(settings, "user_id", "", "").
+		ExecuteTo(&result)
+	if err != nil {
+		utils.Error(c, 500, "保存设置失败: "+err.Error())
+		return
+	}
+
+	utils.GlobalCache.Set(settingsCacheKey(userID), settings)
+	utils.Success(c, settings)
+}
+
+// validateBalanceTargets 校验平衡目标: 标签合法、占比非负且合计为 100 (空表示清除目标)
+func validateBalanceTargets(targets map[string]float64) string {
+	if len(targets) == 0 {
+		return ""
+	}
+	total := 0.0
+	for tag, percent := range targets {
+		if models.ValidateTag(tag) != tag {
+			return "balance_targets 中的标签不合法: " + tag
+		}
+		if percent < 0 {
+			return "balance_targets 的占比不能为负数"
+		}
+		total += percent
+	}
+	if math.Abs(total-100) > 0.01 {
+		return "balance_targets 的占比合计需为 100"
+	}
+	return ""
+}
+
+// settingsLocation 用户设置的时区，未设置或无法加载时为 UTC
+func settingsLocation(settings models.UserSettings) *time.Location {
+	loc, err := utils.LoadLocation(settings.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// requestLocation 读取 tz 参数，未提供时使用用户设置中的时区
+func requestLocation(c *gin.Context, userID string) (*time.Location, bool) {
+	if tz := c.Query("tz"); tz != "" {
+		loc, err := utils.LoadLocation(tz)
+		if err != nil {
+			utils.ValidationError(c, "tz 时区不正确")
+			return nil, false
+		}
+		return loc, true
+	}
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return nil, false
+	}
+	return settingsLocation(settings), true
+}
+
+// loadSettings 读取用户设置，未保存过时返回默认值
+func loadSettings(userID string) (models.UserSettings, error) {
+	if cached := utils.GlobalCache.Get(settingsCacheKey(userID)); cached != nil {
+		return cached.(models.UserSettings), nil
+	}
+
+	var rows []models.UserSettings
+	_, err := utils.Client.From("user_settings").
+		Select("*", "", false).
+		Eq("user_id", userID).
+		ExecuteTo(&rows)
+	if err != nil {
+		return models.UserSettings{}, err
+	}
+
+	settings := models.DefaultSettings(userID)
+	if len(rows) > 0 {
+		settings = rows[0]
+		if settings.WeekStart == "" {
+			settings.WeekStart = models.WeekStartMonday
+		}
+	}
+
+	utils.GlobalCache.Set(settingsCacheKey(userID), settings)
+	return settings, nil
+}
+
+func settingsCacheKey(userID string) string {
+	return utils.GenerateKey(userID, "settings", "")
+}
@@ -2,65 +2,82 @@ package handlers
 
 import (
 	"fmt"
-	"math"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
 	"github.com/user/daily-records-backend/models"
 	"github.com/user/daily-records-backend/utils"
 )
 
 // GetWeekStat 获取周统计
+//
+// 时间范围支持 iso_week=2026-W07、week=current|previous 或 week_start/week_end，
+// 一周的第一天取用户设置 (周一或周日)。默认返回标签统计数组，实际使用的范围放在
+// X-Week-Start、X-Week-End 响应头中；detail=true 时返回包含范围与周状态的对象。
 func GetWeekStat(c *gin.Context) {
 	userID := c.GetString("user_id")
-	weekStart := c.Query("week_start") // 2026-02-16
-	weekEnd := c.Query("week_end")     // 2026-02-22
 
-	if weekStart == "" || weekEnd == "" {
-		utils.ValidationError(c, "需提供 week_start 和 week_end")
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
 		return
 	}
-
-	// 尝试从缓存获取
-	cacheKey := utils.GenerateKey(userID, "week", weekStart+"_"+weekEnd)
+	start, end, err := resolveWeek(c, settings)
+	if err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+	weekStart, weekEnd := start.Format(utils.DateLayout), end.Format(utils.DateLayout)
+	c.Header("X-Week-Start", weekStart)
+	c.Header("X-Week-End", weekEnd)
+	detail := c.Query("detail") == "true"
+
+	// 尝试从缓存获取 (区间状态随日期变化，键中包含今天的日期)
+	loc := settingsLocation(settings)
+	today := utils.Today(loc)
+	cacheKey := utils.GenerateKey(userID, "week", today.Format(utils.DateLayout)+"_"+weekStart+"_"+weekEnd+"_"+settings.Timezone)
 	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
-		utils.Success(c, cached)
+		writeWeekStat(c, cached.(models.WeekStatResponse), detail)
 		return
 	}
 
 	// 查询数据
-	var records []models.Record
-	_, err := utils.Client.From("daily_records").
-		Select("*", "exact", false).
-		Eq("user_id", userID).
-		Gte("created_at", weekStart+" 00:00:00").
-		Lte("created_at", weekEnd+" 23:59:59").
-		ExecuteTo(&records)
-
+	q := aggregate.Query{From: start, To: end, Location: loc, GroupBy: []aggregate.GroupBy{aggregate.ByTag}}
+	rows, err := loadDailyRows(userID, q)
 	if err != nil {
 		utils.Error(c, 500, "查询数据失败")
 		return
 	}
 
 	// 聚合统计
-	tagMap := make(map[string]*models.WeekStat)
-	for _, r := range records {
-		if _, ok := tagMap[r.Tag]; !ok {
-			tagMap[r.Tag] = &models.WeekStat{Tag: r.Tag}
-		}
-		tagMap[r.Tag].Count++
-		tagMap[r.Tag].TotalHours += float64(r.Duration) / 60.0
+	res := aggregate.Run(rows, q)
+	aggregate.SortByMinutes(res.Buckets)
+
+	stats := models.WeekStatResponse{
+		PeriodStatus: periodStatus(start, end, today),
+		WeekStart:    weekStart,
+		WeekEnd:      weekEnd,
+		WeekStartsOn: settings.WeekStart,
+		TagStats:     make([]models.WeekStat, 0, len(res.Buckets)),
 	}
-
-	var stats []models.WeekStat
-	for _, s := range tagMap {
-		s.TotalHours = math.Round(s.TotalHours*100) / 100
-		stats = append(stats, *s)
+	for _, b := range res.Buckets {
+		stats.TagStats = append(stats.TagStats, models.WeekStat{Tag: b.Tag, Count: b.Count, TotalHours: b.Hours()})
 	}
 
 	// 存入缓存
 	utils.GlobalCache.Set(cacheKey, stats)
-	utils.Success(c, stats)
+	writeWeekStat(c, stats, detail)
+}
+
+// writeWeekStat 返回周统计，detail 为 false 时只返回标签统计数组 (兼容旧版客户端)
+func writeWeekStat(c *gin.Context, stats models.WeekStatResponse, detail bool) {
+	if detail {
+		utils.Success(c, stats)
+		return
+	}
+	utils.Success(c, stats.TagStats)
 }
 
 // GetYearStat 获取年统计
@@ -72,25 +89,29 @@ func GetYearStat(c *gin.Context) {
 		return
 	}
 
+	year, err := utils.ParseYear(yearStr)
+	if err != nil {
+		utils.ValidationError(c, "year 格式不正确")
+		return
+	}
+
+	// 读取设置失败时不计算平衡评分
+	settings, err := loadSettings(userID)
+	if err != nil {
+		settings = models.DefaultSettings(userID)
+	}
+
 	// 尝试从缓存获取
-	cacheKey := utils.GenerateKey(userID, "year", yearStr)
+	today := utils.Today(time.UTC)
+	cacheKey := utils.GenerateKey(userID, "year", today.Format(utils.DateLayout)+"_"+yearStr+"_"+targetsKey(settings.BalanceTargets))
 	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
 		utils.Success(c, cached)
 		return
 	}
 
-	// 查询全年数据 (使用范围查询替代 Like，对 TIMESTAMP 更友好)
-	nextYear, _ := strconv.Atoi(yearStr)
-	nextYearStr := strconv.Itoa(nextYear + 1)
-
-	var records []models.Record
-	_, err := utils.Client.From("daily_records").
-		Select("*", "exact", false).
-		Eq("user_id", userID).
-		Gte("created_at", yearStr+"-01-01 00:00:00").
-		Lt("created_at", nextYearStr+"-01-01 00:00:00").
-		ExecuteTo(&records)
-
+	// 查询全年数据
+	q := yearQuery(year)
+	rows, err := loadDailyRows(userID, q)
 	if err != nil {
 		utils.Error(c, 500, "查询全年数据失败")
 		return
@@ -98,39 +119,23 @@ func GetYearStat(c *gin.Context) {
 
 	// 聚合逻辑 (含标签比例和每月分布)
 	yearStat := models.YearStat{
-		MonthHours: make([]models.MonthHour, 12),
+		PeriodStatus: periodStatus(q.From, q.To, today),
+		MonthHours:   make([]models.MonthHour, 0, 12),
 	}
-	for i := 0; i < 12; i++ {
-		yearStat.MonthHours[i].Month = i + 1
-	}
-
-	tagMap := make(map[string]*models.YearTagStat)
-	var totalHours float64 = 0
 
-	for _, r := range records {
-		// 标签聚合
-		if _, ok := tagMap[r.Tag]; !ok {
-			tagMap[r.Tag] = &models.YearTagStat{Tag: r.Tag}
-		}
-		hours := float64(r.Duration) / 60.0
-		tagMap[r.Tag].Count++
-		tagMap[r.Tag].TotalHours += hours
-		totalHours += hours
-
-		// 月份聚合 (解析 created_at 格式 "2026-02-21...")
-		if len(r.CreatedAt) >= 7 {
-			month, _ := strconv.Atoi(r.CreatedAt[5:7])
-			if month >= 1 && month <= 12 {
-				yearStat.MonthHours[month-1].TotalHours += hours
-			}
-		}
+	monthQ := q
+	monthQ.Granularity = aggregate.Month
+	monthQ.FillEmpty = true
+	for _, b := range aggregate.Run(rows, monthQ).Buckets {
+		yearStat.MonthHours = append(yearStat.MonthHours, models.MonthHour{
+			Month:      int(b.Start.Month()),
+			TotalHours: b.Hours(),
+		})
 	}
 
-	// 计算比例和极值
+	// 计算极值
 	maxH, minH := -1.0, 10000000.0
-	for i, mh := range yearStat.MonthHours {
-		mh.TotalHours = math.Round(mh.TotalHours*100) / 100
-		yearStat.MonthHours[i] = mh
+	for _, mh := range yearStat.MonthHours {
 		if mh.TotalHours > maxH {
 			maxH = mh.TotalHours
 			yearStat.MaxMonth = mh.Month
@@ -143,12 +148,34 @@ func GetYearStat(c *gin.Context) {
 		}
 	}
 
-	for _, ts := range tagMap {
-		if totalHours > 0 {
-			ts.Ratio = math.Round((ts.TotalHours/totalHours)*10000) / 100
+	// 标签聚合 (比例为百分比，保留两位小数)
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	tagRes := aggregate.Run(rows, tagQ)
+	aggregate.SortByMinutes(tagRes.Buckets)
+	for _, b := range tagRes.Buckets {
+		yearStat.TagStats = append(yearStat.TagStats, models.YearTagStat{
+			Tag:        b.Tag,
+			Count:      b.Count,
+			TotalHours: b.Hours(),
+			Ratio:      aggregate.Round(tagRes.Ratio(b)*100, 2),
+		})
+	}
+
+	// 生活平衡 (用户设置了目标分配时)
+	if len(settings.BalanceTargets) > 0 {
+		tagMinutes := make(map[string]int)
+		for _, b := range tagRes.Buckets {
+			tagMinutes[b.Tag] = b.Minutes
 		}
-		ts.TotalHours = math.Round(ts.TotalHours*100) / 100
-		yearStat.TagStats = append(yearStat.TagStats, *ts)
+		balance := balanceScore(tagMinutes, settings.BalanceTargets)
+		balance.Period = strconv.Itoa(year)
+		balance.Start = q.From.Format(utils.DateLayout)
+		yearStat.Balance = &balance
+
+		monthTagQ := tagQ
+		monthTagQ.Granularity = aggregate.Month
+		yearStat.MonthlyBalance = balanceByPeriod(aggregate.Run(rows, monthTagQ).Buckets, monthTagQ, settings.BalanceTargets)
 	}
 
 	// 存入缓存
@@ -156,14 +183,62 @@ func GetYearStat(c *gin.Context) {
 	utils.Success(c, yearStat)
 }
 
-// ExportWeek 导出周文本总结
+// ExportWeek 导出周文本总结 (时间范围参数同 GetWeekStat)
+//
+// format=csv 导出记录表格，format=pdf 导出 PDF 周报；指定 template_id 时使用用户的自定义模板。
 func ExportWeek(c *gin.Context) {
 	userID := c.GetString("user_id")
-	weekStart := c.Query("week_start")
-	weekEnd := c.Query("week_end")
 
-	var records []models.Record
-	utils.Client.From("daily_records").Select("*", "exact", false).Eq("user_id", userID).Gte("created_at", weekStart).Lte("created_at", weekEnd).ExecuteTo(&records)
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return
+	}
+	start, end, err := resolveWeek(c, settings)
+	if err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+	weekStart, weekEnd := start.Format(utils.DateLayout), end.Format(utils.DateLayout)
+
+	loc := settingsLocation(settings)
+	records, err := fetchRecordsBetween(userID, start, end, loc)
+	if err != nil {
+		utils.Error(c, 500, "查询数据失败")
+		return
+	}
+
+	// 实际使用的时间范围
+	c.Header("X-Week-Start", weekStart)
+	c.Header("X-Week-End", weekEnd)
+
+	if id := c.Query("template_id"); id != "" {
+		data := buildTemplateData(models.TemplateKindWeek, "周总结", start, end, loc, records, settings)
+		renderTemplateExport(c, userID, id, data)
+		return
+	}
+
+	if c.Query("format") == "pdf" {
+		writeWeekPDF(c, start, end, loc, records)
+		return
+	}
+
+	if c.Query("format") == "csv" {
+		columns, err := parseRecordColumns(c.Query("columns"))
+		if err != nil {
+			utils.ValidationError(c, err.Error())
+			return
+		}
+		w := startCSV(c, exportFilename("week", start, end, "csv"))
+		w.Write(recordHeader(columns))
+		for _, r := range records {
+			if line, ok := recordLine(r, columns, loc); ok {
+				w.Write(line)
+			}
+		}
+		w.Flush()
+		return
+	}
 
 	summary := fmt.Sprintf("📅 周总结 (%s ~ %s)\n\n", weekStart, weekEnd)
 	total := 0
@@ -177,30 +252,75 @@ func ExportWeek(c *gin.Context) {
 }
 
 // ExportYear 导出年文本总结
+//
+// format=markdown 导出年度回顾，format=xlsx 导出工作簿，format=pdf 导出 PDF 年报；
+// 指定 template_id 时使用用户的自定义模板。
 func ExportYear(c *gin.Context) {
 	userID := c.GetString("user_id")
 	year := c.Query("year")
 
-	nextYear, _ := strconv.Atoi(year)
-	nextYearStr := strconv.Itoa(nextYear + 1)
+	y, err := utils.ParseYear(year)
+	if err != nil {
+		utils.ValidationError(c, "year 格式不正确")
+		return
+	}
 
-	var records []models.Record
-	utils.Client.From("daily_records").
-		Select("*", "exact", false).
-		Eq("user_id", userID).
-		Gte("created_at", year+"-01-01 00:00:00").
-		Lt("created_at", nextYearStr+"-01-01 00:00:00").
-		ExecuteTo(&records)
+	if id := c.Query("template_id"); id != "" {
+		if data, ok := templateDataFromQuery(c, userID, models.TemplateKindYear); ok {
+			renderTemplateExport(c, userID, id, data)
+		}
+		return
+	}
+
+	if c.Query("format") == "pdf" {
+		writeYearPDF(c, userID, y)
+		return
+	}
+
+	if c.Query("format") == "xlsx" {
+		q := yearQuery(y)
+		writeWorkbook(c, userID, q.From, q.To, q.Location, year+".xlsx")
+		return
+	}
+
+	if c.Query("format") == "markdown" {
+		review, err := buildYearReview(userID, y)
+		if err != nil {
+			utils.Error(c, 500, "生成年度回顾失败")
+			return
+		}
+		c.Header("Content-Type", "text/markdown; charset=utf-8")
+		c.String(200, renderYearReviewMarkdown(review))
+		return
+	}
+
+	q := yearQuery(y)
+	q.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "查询全年数据失败")
+		return
+	}
+	res := aggregate.Run(rows, q)
+	aggregate.SortByMinutes(res.Buckets)
 
 	summary := fmt.Sprintf("🏆 %s年度精进报告\n\n", year)
 	tagTotal := make(map[string]int)
-	for _, r := range records {
-		tagTotal[r.Tag] += r.Duration
+	summary += "核心产出统计:\n"
+	for _, b := range res.Buckets {
+		tagTotal[b.Tag] = b.Minutes
+		summary += fmt.Sprintf("- %s: %.1f 小时\n", b.Tag, float64(b.Minutes)/60.0)
 	}
 
-	summary += "核心产出统计:\n"
-	for tag, dur := range tagTotal {
-		summary += fmt.Sprintf("- %s: %.1f 小时\n", tag, float64(dur)/60.0)
+	// 生活平衡 (用户设置了目标分配时)
+	if settings, err := loadSettings(userID); err == nil && len(settings.BalanceTargets) > 0 {
+		balance := balanceScore(tagTotal, settings.BalanceTargets)
+		if balance.Score != nil {
+			summary += fmt.Sprintf("\n生活平衡评分: %.1f / 100\n", *balance.Score)
+			for _, d := range balance.Tags {
+				summary += fmt.Sprintf("- %s: 实际 %.1f%% / 目标 %.1f%%\n", d.Tag, d.ActualPercent, d.TargetPercent)
+			}
+		}
 	}
 
 	c.String(200, summary)
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"math"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
 	"github.com/user/daily-records-backend/models"
 	"github.com/user/daily-records-backend/utils"
 )
@@ -17,25 +19,23 @@ func GetYearlyStats(c *gin.Context) {
 		yearStr = strconv.Itoa(time.Now().Year())
 	}
 
-	// 尝试从缓存获取
-	cacheKey := utils.GenerateKey(userID, "yearly_stats", yearStr)
+	// 尝试从缓存获取 (区间状态与日均值随日期变化，键中包含今天的日期)
+	today := utils.Today(time.UTC)
+	cacheKey := utils.GenerateKey(userID, "yearly_stats", today.Format(utils.DateLayout)+"_"+yearStr)
 	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
 		utils.Success(c, cached)
 		return
 	}
 
 	// 计算时间范围
-	start := yearStr + "-01-01 00:00:00"
-	end := yearStr + "-12-31 23:59:59"
-
-	var records []models.Record
-	_, err := utils.Client.From("daily_records").
-		Select("*", "exact", false).
-		Eq("user_id", userID).
-		Gte("created_at", start).
-		Lte("created_at", end).
-		ExecuteTo(&records)
+	year, err := utils.ParseYear(yearStr)
+	if err != nil {
+		utils.ValidationError(c, "year 格式不正确")
+		return
+	}
 
+	q := yearQuery(year)
+	rows, err := loadDailyRows(userID, q)
 	if err != nil {
 		utils.Error(c, 500, "获取年度数据失败")
 		return
@@ -43,46 +43,39 @@ func GetYearlyStats(c *gin.Context) {
 
 	// 计算统计数据
 	stats := models.YearlyStatsResponse{
+		PeriodStatus: periodStatus(q.From, q.To, today),
+		Averages:     dailyAverages(rows, q, today),
 		TagStats:     make([]models.YearlyTagStat, 0),
-		MonthlyTrend: make([]models.MonthlyTrend, 12),
+		MonthlyTrend: make([]models.MonthlyTrend, 0, 12),
 	}
 
-	for i := 0; i < 12; i++ {
-		stats.MonthlyTrend[i].Month = i + 1
-	}
-
-	tagMap := make(map[string]*models.YearlyTagStat)
-	totalDuration := 0
-	totalRecords := len(records)
-
-	for _, r := range records {
-		totalDuration += r.Duration
-
-		// 标签统计
-		if _, ok := tagMap[r.Tag]; !ok {
-			tagMap[r.Tag] = &models.YearlyTagStat{Tag: r.Tag}
-		}
-		tagMap[r.Tag].Count++
-		tagMap[r.Tag].Duration += r.Duration
-
-		// 月度趋势
-		if len(r.CreatedAt) >= 7 {
-			month, _ := strconv.Atoi(r.CreatedAt[5:7])
-			if month >= 1 && month <= 12 {
-				stats.MonthlyTrend[month-1].Count++
-				stats.MonthlyTrend[month-1].Duration += r.Duration
-			}
-		}
+	// 月度趋势
+	monthQ := q
+	monthQ.Granularity = aggregate.Month
+	monthQ.FillEmpty = true
+	for _, b := range aggregate.Run(rows, monthQ).Buckets {
+		stats.MonthlyTrend = append(stats.MonthlyTrend, models.MonthlyTrend{
+			Month:    int(b.Start.Month()),
+			Count:    b.Count,
+			Duration: b.Minutes,
+		})
 	}
 
-	stats.TotalRecords = totalRecords
-	stats.TotalDuration = totalDuration
+	// 标签统计
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	tagRes := aggregate.Run(rows, tagQ)
+	aggregate.SortByMinutes(tagRes.Buckets)
 
-	for _, s := range tagMap {
-		if totalDuration > 0 {
-			s.Ratio = float64(s.Duration) / float64(totalDuration)
-		}
-		stats.TagStats = append(stats.TagStats, *s)
+	stats.TotalRecords = tagRes.TotalCount
+	stats.TotalDuration = tagRes.TotalMinutes
+	for _, b := range tagRes.Buckets {
+		stats.TagStats = append(stats.TagStats, models.YearlyTagStat{
+			Tag:      b.Tag,
+			Count:    b.Count,
+			Duration: b.Minutes,
+			Ratio:    tagRes.Ratio(b),
+		})
 	}
 
 	// 存入缓存
@@ -107,57 +100,321 @@ func GetMonthlyStats(c *gin.Context) {
 		monthStr = "0" + monthStr
 	}
 
-	cacheKey := utils.GenerateKey(userID, "monthly_stats", yearStr+"-"+monthStr)
+	today := utils.Today(time.UTC)
+	cacheKey := utils.GenerateKey(userID, "monthly_stats", today.Format(utils.DateLayout)+"_"+yearStr+"-"+monthStr)
 	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
 		utils.Success(c, cached)
 		return
 	}
 
-	// 简单的月度末尾逻辑 (或者直接用下个月减一秒)
-	start := yearStr + "-" + monthStr + "-01 00:00:00"
+	// 计算时间范围
+	year, err1 := utils.ParseYear(yearStr)
+	month, err2 := strconv.Atoi(monthStr)
+	if err1 != nil || err2 != nil || month < 1 || month > 12 {
+		utils.ValidationError(c, "year 或 month 格式不正确")
+		return
+	}
+	firstDay := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	lastDay := firstDay.AddDate(0, 1, -1)
 
-	// 计算结束时间
-	year, _ := strconv.Atoi(yearStr)
-	month, _ := strconv.Atoi(monthStr)
-	nextMonth := time.Date(year, time.Month(month+1), 1, 0, 0, 0, 0, time.UTC)
-	end := nextMonth.Add(-time.Second).Format("2006-01-02 15:04:05")
+	q := aggregate.Query{
+		From:     firstDay,
+		To:       lastDay,
+		Location: time.UTC,
+		GroupBy:  []aggregate.GroupBy{aggregate.ByTag},
+	}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取月度数据失败")
+		return
+	}
+
+	res := aggregate.Run(rows, q)
+	aggregate.SortByMinutes(res.Buckets)
+
+	stats := models.MonthlyStatsResponse{
+		PeriodStatus: periodStatus(firstDay, lastDay, today),
+		Averages:     dailyAverages(rows, q, today),
+		TagStats:     make([]models.MonthlyTagStat, 0),
+	}
 
-	var records []models.Record
-	_, err := utils.Client.From("daily_records").
-		Select("*", "exact", false).
-		Eq("user_id", userID).
-		Gte("created_at", start).
-		Lte("created_at", end).
-		ExecuteTo(&records)
+	// 日均记录数 (在这个月已经过去的天数中，未来月份为 0)
+	stats.DailyAverage = stats.Averages.RecordsPerElapsedDay
 
+	for _, b := range res.Buckets {
+		stats.TagStats = append(stats.TagStats, models.MonthlyTagStat{Tag: b.Tag, Count: b.Count, Duration: b.Minutes})
+	}
+
+	utils.GlobalCache.Set(cacheKey, stats)
+	utils.Success(c, stats)
+}
+
+// GetHeatmap 获取年度每日活跃热力图 (可按标签筛选)
+func GetHeatmap(c *gin.Context) {
+	userID := c.GetString("user_id")
+	yearStr := c.Query("year")
+	if yearStr == "" {
+		yearStr = strconv.Itoa(time.Now().Year())
+	}
+	year, err := utils.ParseYear(yearStr)
 	if err != nil {
-		utils.Error(c, 500, "获取月度数据失败")
+		utils.ValidationError(c, "year 格式不正确")
+		return
+	}
+	tag := c.Query("tag")
+
+	today := utils.Today(time.UTC)
+	cacheKey := utils.GenerateKey(userID, "heatmap", today.Format(utils.DateLayout)+"_"+yearStr+"_"+tag)
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
 		return
 	}
 
-	totalRecords := len(records)
-	tagMap := make(map[string]*models.MonthlyTagStat)
+	q := yearQuery(year)
+	q.Granularity = aggregate.Day
+	q.Tag = tag
+	q.FillEmpty = true // 生成全年每一天 (自动处理闰年)
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取热力图数据失败")
+		return
+	}
 
-	for _, r := range records {
-		if _, ok := tagMap[r.Tag]; !ok {
-			tagMap[r.Tag] = &models.MonthlyTagStat{Tag: r.Tag}
+	resp := models.HeatmapResponse{
+		PeriodStatus: periodStatus(q.From, q.To, today),
+		Year:         year,
+		Tag:          tag,
+		Days:         make([]models.HeatmapDay, 0, 366),
+	}
+	for _, b := range aggregate.Run(rows, q).Buckets {
+		if b.Minutes > resp.MaxMinutes {
+			resp.MaxMinutes = b.Minutes
 		}
-		tagMap[r.Tag].Count++
-		tagMap[r.Tag].Duration += r.Duration
+		resp.Days = append(resp.Days, models.HeatmapDay{Date: b.Period, Minutes: b.Minutes, Count: b.Count})
 	}
 
-	stats := models.MonthlyStatsResponse{
-		TagStats: make([]models.MonthlyTagStat, 0),
+	for i := range resp.Days {
+		resp.Days[i].Level = heatmapLevel(resp.Days[i], resp.MaxMinutes)
 	}
 
-	// 计算日均记录数 (在这个月已经过去的天数中)
-	daysInMonth := nextMonth.Add(-time.Second).Day()
-	stats.DailyAverage = float64(totalRecords) / float64(daysInMonth)
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
+}
 
-	for _, s := range tagMap {
-		stats.TagStats = append(stats.TagStats, *s)
+// heatmapLevel 按当年单日最大时长将活跃度量化为 0-4 级
+func heatmapLevel(day models.HeatmapDay, maxMinutes int) int {
+	if day.Count == 0 {
+		return 0
 	}
+	if maxMinutes <= 0 {
+		return 1 // 有记录但时长均为 0
+	}
+	level := int(math.Ceil(float64(day.Minutes) * 4 / float64(maxMinutes)))
+	if level < 1 {
+		level = 1
+	}
+	if level > 4 {
+		level = 4
+	}
+	return level
+}
 
-	utils.GlobalCache.Set(cacheKey, stats)
-	utils.Success(c, stats)
+// QueryStats 通用统计查询
+//
+// 参数: from/to 日期闭区间，tz 时区，granularity=day|week|month|quarter|year (缺省不切分)，
+// group_by=tag,weekday,hour (可组合)，metrics=count,minutes,hours,ratio,avg_minutes (缺省全部)，
+// tag 仅统计指定标签，week_start=sunday 时按周日开始的周聚合。
+func QueryStats(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, err := utils.ParseDateRange(c.Query("from"), c.Query("to"), maxRangeDays)
+	if err != nil {
+		utils.ValidationError(c, "需提供正确的 from 和 to (格式: 2026-02-16，跨度不超过两年)")
+		return
+	}
+	loc, err := utils.LoadLocation(c.Query("tz"))
+	if err != nil {
+		utils.ValidationError(c, "tz 时区不正确")
+		return
+	}
+	granularity, ok := aggregate.ParseGranularity(c.Query("granularity"))
+	if !ok {
+		utils.ValidationError(c, "granularity 仅支持 day、week、month、quarter、year")
+		return
+	}
+	groupBy, ok := aggregate.ParseGroupBy(c.Query("group_by"))
+	if !ok {
+		utils.ValidationError(c, "group_by 仅支持 tag、weekday、hour")
+		return
+	}
+	metrics, ok := aggregate.ParseMetrics(c.Query("metrics"))
+	if !ok {
+		utils.ValidationError(c, "metrics 仅支持 count、minutes、hours、ratio、avg_minutes")
+		return
+	}
+
+	q := aggregate.Query{
+		From:        from,
+		To:          to,
+		Location:    loc,
+		Granularity: granularity,
+		GroupBy:     groupBy,
+		Tag:         c.Query("tag"),
+		SundayFirst: c.Query("week_start") == "sunday",
+		FillEmpty:   true,
+	}
+
+	today := utils.Today(loc)
+	cacheKey := utils.GenerateKey(userID, "query", today.Format(utils.DateLayout)+"_"+c.Request.URL.RawQuery)
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	rows, err := loadRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "查询统计数据失败")
+		return
+	}
+	res := aggregate.Run(rows, q)
+
+	resp := models.QueryResponse{
+		PeriodStatus: periodStatus(from, to, today),
+		From:         from.Format(utils.DateLayout),
+		To:           to.Format(utils.DateLayout),
+		Timezone:     loc.String(),
+		Granularity:  string(granularity),
+		GroupBy:      make([]string, 0, len(groupBy)),
+		Total:        queryValues(res, res.Total(), metrics),
+		Buckets:      make([]models.QueryBucket, 0, len(res.Buckets)),
+	}
+	for _, g := range groupBy {
+		resp.GroupBy = append(resp.GroupBy, string(g))
+	}
+
+	for _, b := range res.Buckets {
+		qb := models.QueryBucket{Period: b.Period, Values: queryValues(res, b, metrics)}
+		if granularity != aggregate.None {
+			qb.Start = b.Start.Format(utils.DateLayout)
+		}
+		if len(groupBy) > 0 {
+			qb.Group = make(map[string]interface{})
+			for _, g := range groupBy {
+				switch g {
+				case aggregate.ByTag:
+					qb.Group[string(g)] = b.Tag
+				case aggregate.ByWeekday:
+					qb.Group[string(g)] = b.Weekday
+				case aggregate.ByHour:
+					qb.Group[string(g)] = b.Hour
+				}
+			}
+		}
+		resp.Buckets = append(resp.Buckets, qb)
+	}
+
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
+}
+
+// queryValues 按请求的指标取值
+func queryValues(res aggregate.Result, b aggregate.Bucket, metrics []aggregate.Metric) map[string]float64 {
+	values := make(map[string]float64, len(metrics))
+	for _, m := range metrics {
+		values[string(m)] = res.Value(b, m)
+	}
+	return values
+}
+
+// maxRangeDays 自定义范围统计允许的最大跨度
+const maxRangeDays = 731
+
+// GetRangeStats 获取任意日期范围的统计
+//
+// 参数: from/to 日期闭区间 (跨度不超过两年)，tz 时区 (缺省 UTC)
+func GetRangeStats(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, err := utils.ParseDateRange(c.Query("from"), c.Query("to"), maxRangeDays)
+	if err != nil {
+		utils.ValidationError(c, "需提供正确的 from 和 to (格式: 2026-02-16，跨度不超过两年)")
+		return
+	}
+	loc, err := utils.LoadLocation(c.Query("tz"))
+	if err != nil {
+		utils.ValidationError(c, "tz 时区不正确")
+		return
+	}
+
+	today := utils.Today(loc)
+	cacheKey := utils.GenerateKey(userID, "range",
+		today.Format(utils.DateLayout)+"_"+from.Format(utils.DateLayout)+"_"+to.Format(utils.DateLayout)+"_"+loc.String())
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	q := aggregate.Query{From: from, To: to, Location: loc}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取范围统计数据失败")
+		return
+	}
+
+	// 标签统计
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	tagRes := aggregate.Run(rows, tagQ)
+	aggregate.SortByMinutes(tagRes.Buckets)
+
+	resp := models.RangeStatsResponse{
+		PeriodStatus: periodStatus(from, to, today),
+		Averages:     dailyAverages(rows, q, today),
+		From:         from.Format(utils.DateLayout),
+		To:           to.Format(utils.DateLayout),
+		TotalRecords: tagRes.TotalCount,
+		TotalMinutes: tagRes.TotalMinutes,
+		TotalHours:   aggregate.RoundHours(tagRes.TotalMinutes),
+		TagStats:     make([]models.RangeTagStat, 0, len(tagRes.Buckets)),
+		Days:         make([]models.RangeDayStat, 0, utils.DaysBetween(from, to)),
+	}
+	for _, b := range tagRes.Buckets {
+		resp.TagStats = append(resp.TagStats, models.RangeTagStat{
+			Tag:     b.Tag,
+			Count:   b.Count,
+			Minutes: b.Minutes,
+			Hours:   b.Hours(),
+			Ratio:   tagRes.Ratio(b),
+		})
+	}
+
+	// 每日序列，最少一天只统计已经过去的天数 (含今天)
+	resp.ElapsedDays = resp.Averages.ElapsedDays
+	resp.DailyAverage = resp.Averages.MinutesPerElapsedDay
+
+	dayQ := q
+	dayQ.Granularity = aggregate.Day
+	dayQ.FillEmpty = true
+	for _, b := range aggregate.Run(rows, dayQ).Buckets {
+		day := models.RangeDayStat{Date: b.Period, Count: b.Count, Minutes: b.Minutes}
+		resp.Days = append(resp.Days, day)
+
+		if resp.BusiestDay == nil || day.Minutes > resp.BusiestDay.Minutes {
+			d := day
+			resp.BusiestDay = &d
+		}
+		if date, _ := utils.ParseDate(b.Period); date.After(today) {
+			continue
+		}
+		if resp.QuietestDay == nil || day.Minutes < resp.QuietestDay.Minutes {
+			d := day
+			resp.QuietestDay = &d
+		}
+	}
+	if resp.TotalMinutes == 0 {
+		resp.BusiestDay = nil // 没有任何时长时不存在"最忙的一天"
+	}
+
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
 }
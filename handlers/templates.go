@@ -0,0 +1,302 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/tmpl"
+	"github.com/user/daily-records-backend/utils"
+)
+
+var errTemplateNotFound = errors.New("模板不存在")
+
+// ListTemplates 获取当前用户的导出模板 (可按 kind 筛选)
+func ListTemplates(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	query := utils.Client.From("export_templates").
+		Select("*", "", false).
+		Eq("user_id", userID)
+	if kind := c.Query("kind"); kind != "" {
+		query = query.Eq("kind", kind)
+	}
+
+	templates := make([]models.ExportTemplate, 0)
+	_, err := query.Order("created_at", &utils.OrderOptions{Ascending: true}).ExecuteTo(&templates)
+	if err != nil {
+		utils.Error(c, 500, "获取模板失败")
+		return
+	}
+	utils.Success(c, templates)
+}
+
+// CreateTemplate 新建导出模板，保存前检查模板语法
+func CreateTemplate(c *gin.Context) {
+	var t models.ExportTemplate
+	if err := c.ShouldBindJSON(&t); err != nil {
+		utils.ValidationError(c, "name 不能为空且不超过 50 字，kind 仅支持 week、year，body 不能为空")
+		return
+	}
+	if _, err := tmpl.Parse(t.Body); err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+	t.ID = ""
+	t.UserID = c.GetString("user_id")
+	t.CreatedAt, t.UpdatedAt = "", ""
+
+	var result []models.ExportTemplate
+	_, err := utils.Client.From("export_templates").Insert(t, false, "", "", "").ExecuteTo(&result)
+	if err != nil || len(result) == 0 {
+		utils.Error(c, 500, "保存模板失败")
+		return
+	}
+	utils.Success(c, result[0])
+}
+
+// UpdateTemplate 修改导出模板
+func UpdateTemplate(c *gin.Context) {
+	if !uuidPattern.MatchString(c.Param("id")) {
+		utils.Error(c, 404, errTemplateNotFound.Error())
+		return
+	}
+	var t models.ExportTemplate
+	if err := c.ShouldBindJSON(&t); err != nil {
+		utils.ValidationError(c, "name 不能为空且不超过 50 字，kind 仅支持 week、year，body 不能为空")
+		return
+	}
+	if _, err := tmpl.Parse(t.Body); err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+
+	update := map[string]interface{}{
+		"name":       t.Name,
+		"kind":       t.Kind,
+		"body":       t.Body,
+		"updated_at": time.Now().UTC().Format(time.RFC3339),
+	}
+	var result []models.ExportTemplate
+	_, err := utils.Client.From("export_templates").
+		Update(update, "", "").
+		Eq("id", c.Param("id")).
+		Eq("user_id", c.GetString("user_id")).
+		ExecuteTo(&result)
+	if err != nil {
+		utils.Error(c, 500, "保存模板失败")
+		return
+	}
+	if len(result) == 0 {
+		utils.Error(c, 404, errTemplateNotFound.Error())
+		return
+	}
+	utils.Success(c, result[0])
+}
+
+// DeleteTemplate 删除导出模板
+func DeleteTemplate(c *gin.Context) {
+	if !uuidPattern.MatchString(c.Param("id")) {
+		utils.Error(c, 404, errTemplateNotFound.Error())
+		return
+	}
+	_, _, err := utils.Client.From("export_templates").
+		Delete("", "").
+		Eq("id", c.Param("id")).
+		Eq("user_id", c.GetString("user_id")).
+		Execute()
+	if err != nil {
+		utils.Error(c, 500, "删除模板失败")
+		return
+	}
+	utils.Success(c, "删除成功")
+}
+
+// PreviewTemplateRequest 预览模板请求
+type PreviewTemplateRequest struct {
+	Kind string `json:"kind" binding:"required,oneof=week year"`
+	Body string `json:"body" binding:"required"`
+}
+
+// PreviewTemplate 使用真实数据预览模板 (未保存的模板也可预览)
+//
+// 时间范围参数与导出接口相同: 周报同 ExportWeek，年报使用 year。
+// 返回渲染结果以及模板可用的完整数据，便于编写模板时查阅字段。
+func PreviewTemplate(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	var req PreviewTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationError(c, "kind 仅支持 week、year，body 不能为空")
+		return
+	}
+
+	data, ok := templateDataFromQuery(c, userID, req.Kind)
+	if !ok {
+		return
+	}
+	output, err := tmpl.Render(req.Body, data)
+	if err != nil {
+		utils.ValidationError(c, err.Error())
+		return
+	}
+	utils.Success(c, gin.H{"output": output, "data": data})
+}
+
+// templateDataFromQuery 按请求参数中的时间范围构建模板数据，失败时已写入错误响应
+func templateDataFromQuery(c *gin.Context, userID, kind string) (models.TemplateData, bool) {
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return models.TemplateData{}, false
+	}
+
+	var from, to time.Time
+	var title string
+	loc := time.UTC
+	if kind == models.TemplateKindWeek {
+		if from, to, err = resolveWeek(c, settings); err != nil {
+			utils.ValidationError(c, err.Error())
+			return models.TemplateData{}, false
+		}
+		title = "周总结"
+		loc = settingsLocation(settings)
+	} else {
+		yearStr := c.Query("year")
+		if yearStr == "" {
+			yearStr = strconv.Itoa(time.Now().Year())
+		}
+		year, err := utils.ParseYear(yearStr)
+		if err != nil {
+			utils.ValidationError(c, "year 格式不正确")
+			return models.TemplateData{}, false
+		}
+		q := yearQuery(year)
+		from, to = q.From, q.To
+		title = fmt.Sprintf("%d 年度精进报告", year)
+	}
+
+	records, err := fetchRecordsBetween(userID, from, to, loc)
+	if err != nil {
+		utils.Error(c, 500, "查询数据失败")
+		return models.TemplateData{}, false
+	}
+	return buildTemplateData(kind, title, from, to, loc, records, settings), true
+}
+
+// renderTemplateExport 使用用户模板导出文本，template_id 不存在或类型不符时返回错误
+func renderTemplateExport(c *gin.Context, userID, templateID string, data models.TemplateData) {
+	t, err := loadTemplate(userID, templateID)
+	if errors.Is(err, errTemplateNotFound) || err == nil && t.Kind != data.Kind {
+		utils.ValidationError(c, "template_id 对应的模板不存在或不适用于该导出")
+		return
+	}
+	if err != nil {
+		utils.Error(c, 500, "获取模板失败")
+		return
+	}
+
+	output, err := tmpl.Render(t.Body, data)
+	if err != nil {
+		utils.Error(c, 422, err.Error())
+		return
+	}
+	c.String(200, output)
+}
+
+// loadTemplate 读取当前用户的模板
+func loadTemplate(userID, id string) (models.ExportTemplate, error) {
+	// 格式不符的 ID 直接视为不存在，避免数据库的 uuid 转换错误
+	if !uuidPattern.MatchString(id) {
+		return models.ExportTemplate{}, errTemplateNotFound
+	}
+	var rows []models.ExportTemplate
+	_, err := utils.Client.From("export_templates").
+		Select("*", "", false).
+		Eq("id", id).
+		Eq("user_id", userID).
+		ExecuteTo(&rows)
+	if err != nil {
+		return models.ExportTemplate{}, err
+	}
+	if len(rows) == 0 {
+		return models.ExportTemplate{}, errTemplateNotFound
+	}
+	return rows[0], nil
+}
+
+// buildTemplateData 由范围内的原始记录生成模板数据，日期与时间按 loc 时区 (与周报/年报一致)
+func buildTemplateData(kind, title string, from, to time.Time, loc *time.Location, records []models.Record, settings models.UserSettings) models.TemplateData {
+	data := models.TemplateData{
+		Kind:    kind,
+		Title:   title,
+		From:    from.Format(utils.DateLayout),
+		To:      to.Format(utils.DateLayout),
+		Year:    from.Year(),
+		Records: make([]models.TemplateRecord, 0, len(records)),
+		Tags:    make([]models.TemplateTag, 0),
+		Goals:   make([]models.TagDeviation, 0),
+	}
+
+	sorted := make([]timedRecord, 0, len(records))
+	for _, r := range records {
+		if at, ok := aggregate.RecordTime(r); ok {
+			sorted = append(sorted, timedRecord{Record: r, at: at.In(loc)})
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].at.Before(sorted[j].at) })
+	for _, r := range sorted {
+		data.Records = append(data.Records, models.TemplateRecord{
+			Date:    r.at.Format(utils.DateLayout),
+			Time:    r.at.Format("15:04"),
+			Tag:     r.Tag,
+			Content: r.Content,
+			Minutes: r.Duration,
+		})
+	}
+
+	rows := aggregate.FromRecords(records)
+	q := aggregate.Query{From: from, To: to, Location: loc}
+
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	tagRes := aggregate.Run(rows, tagQ)
+	aggregate.SortByMinutes(tagRes.Buckets)
+	tagMinutes := make(map[string]int, len(tagRes.Buckets))
+	for _, b := range tagRes.Buckets {
+		data.Tags = append(data.Tags, models.TemplateTag{
+			Tag:     b.Tag,
+			Count:   b.Count,
+			Minutes: b.Minutes,
+			Hours:   b.Hours(),
+			Percent: aggregate.Round(tagRes.Ratio(b)*100, 1),
+		})
+		tagMinutes[b.Tag] = b.Minutes
+	}
+	data.TotalCount = tagRes.TotalCount
+	data.TotalMinutes = tagRes.TotalMinutes
+	data.TotalHours = aggregate.RoundHours(tagRes.TotalMinutes)
+
+	dayQ := q
+	dayQ.Granularity = aggregate.Day
+	dayQ.FillEmpty = true
+	days := aggregate.Run(rows, dayQ).Buckets
+	for _, b := range days {
+		if b.Count > 0 {
+			data.ActiveDays++
+		}
+	}
+	data.Streak = longestStreak(days)
+
+	if len(settings.BalanceTargets) > 0 {
+		balance := balanceScore(tagMinutes, settings.BalanceTargets)
+		data.Goals = balance.Tags
+		data.BalanceScore = balance.Score
+	}
+	return data
+}
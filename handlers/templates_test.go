@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 格式不符的模板 ID 在访问数据库之前即返回 404
+func TestTemplateIDValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, id := range []string{"abc", "1", "00000000-0000-0000-0000-00000000000g"} {
+		if _, err := loadTemplate("u1", id); !errors.Is(err, errTemplateNotFound) {
+			t.Errorf("loadTemplate(%q) = %v, want errTemplateNotFound", id, err)
+		}
+
+		for name, handler := range map[string]gin.HandlerFunc{"UpdateTemplate": UpdateTemplate, "DeleteTemplate": DeleteTemplate} {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("PUT", "/api/templates/"+id, strings.NewReader(`{"name":"周报","kind":"week","body":"x"}`))
+			c.Params = gin.Params{{Key: "id", Value: id}}
+			handler(c)
+			if body := w.Body.String(); !strings.Contains(body, `"code":404`) {
+				t.Errorf("%s(%q) = %s, want 404", name, id, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// topTransitions 返回的最常见转移数量
+const topTransitions = 10
+
+// timedRecord 带解析后时间的记录
+type timedRecord struct {
+	models.Record
+	at time.Time
+}
+
+// GetTransitions 获取标签转移矩阵与同日共现矩阵
+//
+// 参数: from/to 日期闭区间 (缺省为最近 30 天)，tz 时区 (缺省为用户设置的时区)，
+// from_hour/to_hour 仅统计前一条记录落在该小时范围内的转移 (如 18-23 表示晚间)，
+// max_gap 两条记录间隔超过该分钟数时不视为转移。转移只在同一天内计算。
+func GetTransitions(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	loc, ok := requestLocation(c, userID)
+	if !ok {
+		return
+	}
+	from, to, ok := parseRangeOrDefault(c, loc, 30)
+	if !ok || utils.DaysBetween(from, to) > maxRangeDays {
+		utils.ValidationError(c, "from 或 to 格式不正确 (跨度不超过两年)")
+		return
+	}
+	fromHour, ok1 := queryInt(c, "from_hour", 0, 0, 23)
+	toHour, ok2 := queryInt(c, "to_hour", 23, 0, 23)
+	maxGap, ok3 := queryInt(c, "max_gap", 0, 0, 24*60)
+	if !ok1 || !ok2 || !ok3 || fromHour > toHour {
+		utils.ValidationError(c, "from_hour/to_hour 需为 0-23 且 from_hour <= to_hour，max_gap 需为非负分钟数")
+		return
+	}
+
+	today := utils.Today(loc)
+	cacheKey := utils.GenerateKey(userID, "transitions", today.Format(utils.DateLayout)+"_"+loc.String()+"_"+c.Request.URL.RawQuery)
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	// 转移依赖记录的先后顺序，需要读取原始记录
+	records, err := fetchRecordsBetween(userID, from, to, loc)
+	if err != nil {
+		utils.Error(c, 500, "获取记录失败")
+		return
+	}
+
+	timed := make([]timedRecord, 0, len(records))
+	for _, r := range records {
+		if t, ok := aggregate.RecordTime(r); ok {
+			timed = append(timed, timedRecord{Record: r, at: t.In(loc)})
+		}
+	}
+	sort.SliceStable(timed, func(i, j int) bool { return timed[i].at.Before(timed[j].at) })
+
+	// 标签按出现次数降序排列
+	tagCount := make(map[string]int)
+	for _, r := range timed {
+		tagCount[r.Tag]++
+	}
+	tags := make([]string, 0, len(tagCount))
+	for tag := range tagCount {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tagCount[tags[i]] != tagCount[tags[j]] {
+			return tagCount[tags[i]] > tagCount[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	index := make(map[string]int, len(tags))
+	for i, tag := range tags {
+		index[tag] = i
+	}
+
+	resp := models.TransitionsResponse{
+		PeriodStatus:  periodStatus(from, to, today),
+		From:          from.Format(utils.DateLayout),
+		To:            to.Format(utils.DateLayout),
+		Timezone:      loc.String(),
+		Tags:          tags,
+		Transitions:   intMatrix(len(tags)),
+		Probabilities: make([][]float64, len(tags)),
+		CoOccurrence:  intMatrix(len(tags)),
+		Top:           make([]models.TagTransition, 0, topTransitions),
+	}
+
+	// 转移矩阵: 同一天内相邻的两条记录
+	for i := 1; i < len(timed); i++ {
+		prev, next := timed[i-1], timed[i]
+		if prev.at.Format(utils.DateLayout) != next.at.Format(utils.DateLayout) {
+			continue
+		}
+		if prev.at.Hour() < fromHour || prev.at.Hour() > toHour {
+			continue
+		}
+		if maxGap > 0 && next.at.Sub(prev.at) > time.Duration(maxGap)*time.Minute {
+			continue
+		}
+		resp.Transitions[index[prev.Tag]][index[next.Tag]]++
+	}
+
+	// 同日共现矩阵
+	dayTags := make(map[string]map[int]bool)
+	for _, r := range timed {
+		day := r.at.Format(utils.DateLayout)
+		if dayTags[day] == nil {
+			dayTags[day] = make(map[int]bool)
+		}
+		dayTags[day][index[r.Tag]] = true
+	}
+	for _, set := range dayTags {
+		for i := range set {
+			for j := range set {
+				resp.CoOccurrence[i][j]++
+			}
+		}
+	}
+
+	for i, row := range resp.Transitions {
+		rowTotal := 0
+		for _, n := range row {
+			rowTotal += n
+		}
+		resp.Probabilities[i] = make([]float64, len(tags))
+		for j, n := range row {
+			if rowTotal > 0 {
+				resp.Probabilities[i][j] = aggregate.Round(float64(n)/float64(rowTotal), 4)
+			}
+			if n > 0 {
+				resp.Top = append(resp.Top, models.TagTransition{
+					From:        tags[i],
+					To:          tags[j],
+					Count:       n,
+					Probability: resp.Probabilities[i][j],
+				})
+			}
+		}
+	}
+	sort.SliceStable(resp.Top, func(i, j int) bool { return resp.Top[i].Count > resp.Top[j].Count })
+	if len(resp.Top) > topTransitions {
+		resp.Top = resp.Top[:topTransitions]
+	}
+
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
+}
+
+// queryInt 读取整数查询参数，缺省时返回 def，超出 [min, max] 视为无效
+func queryInt(c *gin.Context, key string, def, min, max int) (int, bool) {
+	s := c.Query(key)
+	if s == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || n > max {
+		return 0, false
+	}
+	return n, true
+}
+
+func intMatrix(n int) [][]int {
+	m := make([][]int, n)
+	for i := range m {
+		m[i] = make([]int, n)
+	}
+	return m
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// GetTrend 获取趋势统计: 7 日/30 日滑动平均、线性回归斜率、较上月变化及月末/年末预测
+//
+// 参数: days 展示的天数 (默认 90，最多 366)，tag 仅返回指定标签，tz 时区 (缺省为用户设置的时区)
+func GetTrend(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	days := 90
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 7 || n > 366 {
+			utils.ValidationError(c, "days 需为 7-366 之间的整数")
+			return
+		}
+		days = n
+	}
+	loc, ok := requestLocation(c, userID)
+	if !ok {
+		return
+	}
+	tagFilter := c.Query("tag")
+
+	today := utils.Today(loc)
+	from := today.AddDate(0, 0, -(days - 1))
+
+	cacheKey := utils.GenerateKey(userID, "trend",
+		today.Format(utils.DateLayout)+"_"+strconv.Itoa(days)+"_"+tagFilter+"_"+loc.String())
+	if cached := utils.GlobalCache.Get(cacheKey); cached != nil {
+		utils.Success(c, cached)
+		return
+	}
+
+	// 多取 59 天用于首日的 30 日均值和较上月对比，并覆盖年初至今
+	loadFrom := from.AddDate(0, 0, -59)
+	yearStart := time.Date(today.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	if yearStart.Before(loadFrom) {
+		loadFrom = yearStart
+	}
+
+	q := aggregate.Query{From: loadFrom, To: today, Location: loc, Granularity: aggregate.Day, Tag: tagFilter}
+	rows, err := loadDailyRows(userID, q)
+	if err != nil {
+		utils.Error(c, 500, "获取趋势数据失败")
+		return
+	}
+
+	// 日期下标: 0 对应 loadFrom
+	total := utils.DaysBetween(loadFrom, today)
+	series := map[string][]float64{"": make([]float64, total)}
+
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	for _, b := range aggregate.Run(rows, tagQ).Buckets {
+		if _, ok := series[b.Tag]; !ok {
+			series[b.Tag] = make([]float64, total)
+		}
+		day, _ := utils.ParseDate(b.Period)
+		i := utils.DaysBetween(loadFrom, day) - 1
+		series[b.Tag][i] += float64(b.Minutes)
+		series[""][i] += float64(b.Minutes)
+	}
+
+	resp := models.TrendResponse{
+		PeriodStatus: models.PeriodCurrent, // 趋势总是截至今天
+		From:         from.Format(utils.DateLayout),
+		To:           today.Format(utils.DateLayout),
+		Timezone:     loc.String(),
+		Overall:      buildTrend("", series[""], loadFrom, today, days),
+		Tags:         make([]models.TagTrend, 0, len(series)-1),
+	}
+	for _, tag := range sortedKeys(series) {
+		if tag != "" {
+			resp.Tags = append(resp.Tags, buildTrend(tag, series[tag], loadFrom, today, days))
+		}
+	}
+
+	utils.GlobalCache.Set(cacheKey, resp)
+	utils.Success(c, resp)
+}
+
+// buildTrend 由每日时长序列 (首项对应 loadFrom，末项对应 today) 计算趋势
+func buildTrend(tag string, daily []float64, loadFrom, today time.Time, days int) models.TagTrend {
+	avg7 := aggregate.RollingAverage(daily, 7)
+	avg30 := aggregate.RollingAverage(daily, 30)
+
+	n := len(daily)
+	trend := models.TagTrend{Tag: tag, Points: make([]models.TrendPoint, 0, days)}
+	for i := n - days; i < n; i++ {
+		trend.Points = append(trend.Points, models.TrendPoint{
+			Date:    loadFrom.AddDate(0, 0, i).Format(utils.DateLayout),
+			Minutes: int(daily[i]),
+			Avg7:    avg7[i],
+			Avg30:   avg30[i],
+		})
+	}
+	trend.SlopePerDay = aggregate.Round(aggregate.LinearSlope(daily[n-days:]), 4)
+
+	// 最近 30 天 vs 之前 30 天
+	recent, previous := sum(daily[n-30:]), sum(daily[n-60:n-30])
+	trend.ChangePercent = deltaPercent(int(recent), int(previous))
+
+	// 按当前节奏预测月末和年末
+	monthDays := today.Day()
+	yearDays := today.YearDay()
+	trend.MonthToDate = int(sum(daily[n-monthDays:]))
+	trend.YearToDate = int(sum(daily[n-yearDays:]))
+
+	daysInMonth := time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	daysInYear := time.Date(today.Year(), 12, 31, 0, 0, 0, 0, time.UTC).YearDay()
+	trend.MonthProjection = int(aggregate.Round(float64(trend.MonthToDate)/float64(monthDays)*float64(daysInMonth), 0))
+	trend.YearProjection = int(aggregate.Round(float64(trend.YearToDate)/float64(yearDays)*float64(daysInYear), 0))
+
+	return trend
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func sortedKeys(m map[string][]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
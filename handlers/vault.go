@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"go.uber.org/zap"
+)
+
+// vaultDay 尚未写出的一天
+type vaultDay struct {
+	date    time.Time
+	records []timedRecord
+}
+
+// vaultSummary 已写出的一天的汇总，用于生成周/月索引页
+type vaultSummary struct {
+	date    time.Time
+	count   int
+	minutes int
+	tags    map[string]int
+}
+
+// ExportVault 导出 Obsidian/Logseq 可直接打开的 Markdown 笔记库 (zip)
+//
+// 每天一个文件 (front-matter 含总时长与标签)，另有周、月索引页。按月分段读取记录并
+// 边读边写入压缩包，范围再大也不会把全部记录留在内存中。参数: from、to、tz
+func ExportVault(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, loc, ok := parseExportRange(c)
+	if !ok {
+		return
+	}
+	settings, err := loadSettings(userID)
+	if err != nil {
+		utils.Error(c, 500, "获取设置失败")
+		return
+	}
+
+	// 首段读取成功后才写出响应头，之后出错只能中断输出
+	var zw *zip.Writer
+	var summaries []vaultSummary
+	pending := make(map[string]*vaultDay)
+
+	flush := func(before time.Time) error {
+		keys := make([]string, 0, len(pending))
+		for key, d := range pending {
+			if d.date.Before(before) {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			s, err := writeVaultDay(zw, pending[key], settings.SundayFirst())
+			if err != nil {
+				return err
+			}
+			summaries = append(summaries, s)
+			delete(pending, key)
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	for chunkStart := from; !chunkStart.After(to); {
+		chunkEnd := time.Date(chunkStart.Year(), chunkStart.Month()+1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+		if chunkEnd.After(to) {
+			chunkEnd = to
+		}
+
+		err := fetchRecordsPaged(userID, chunkStart, chunkEnd, loc, func(page []models.Record) error {
+			for _, r := range page {
+				at, ok := aggregate.RecordTime(r)
+				if !ok {
+					continue
+				}
+				at = at.In(loc)
+				day := utils.DateOf(at)
+				key := day.Format(utils.DateLayout)
+				if pending[key] == nil {
+					pending[key] = &vaultDay{date: day}
+				}
+				pending[key].records = append(pending[key].records, timedRecord{Record: r, at: at})
+			}
+			return nil
+		})
+		if err == nil && zw == nil {
+			c.Header("Content-Type", "application/zip")
+			setAttachment(c, exportFilename("vault", from, to, "zip"))
+			c.Status(200)
+			zw = zip.NewWriter(c.Writer)
+		}
+		// 记录按开始时间读取，分段内的日期在本段读完后即可写出
+		if err == nil {
+			err = flush(chunkEnd.AddDate(0, 0, 1))
+		}
+		if err != nil {
+			abortVault(c, zw, userID, err)
+			return
+		}
+		chunkStart = chunkEnd.AddDate(0, 0, 1)
+	}
+
+	err = writeVaultIndexes(zw, summaries, settings.SundayFirst())
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		abortVault(c, zw, userID, err)
+	}
+}
+
+// abortVault 处理导出中途的错误
+func abortVault(c *gin.Context, zw *zip.Writer, userID string, err error) {
+	if zw == nil {
+		utils.Error(c, 500, "导出笔记库失败")
+		return
+	}
+	utils.GetLogger().Error("导出笔记库中断", zap.String("user_id", userID), zap.Error(err))
+	c.Abort()
+}
+
+// writeVaultDay 写出一天的笔记
+func writeVaultDay(zw *zip.Writer, d *vaultDay, sundayFirst bool) (vaultSummary, error) {
+	sort.SliceStable(d.records, func(i, j int) bool { return d.records[i].at.Before(d.records[j].at) })
+
+	s := vaultSummary{date: d.date, tags: make(map[string]int)}
+	for _, r := range d.records {
+		s.count++
+		s.minutes += r.Duration
+		s.tags[r.Tag] += r.Duration
+	}
+
+	date := d.date.Format(utils.DateLayout)
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "date: %s\n", date)
+	fmt.Fprintf(&b, "records: %d\n", s.count)
+	fmt.Fprintf(&b, "total_minutes: %d\n", s.minutes)
+	fmt.Fprintf(&b, "total_hours: %s\n", strconv.FormatFloat(aggregate.RoundHours(s.minutes), 'f', -1, 64))
+	b.WriteString("tags:\n")
+	for _, tag := range sortedTagsByMinutes(s.tags) {
+		fmt.Fprintf(&b, "  - %s\n", strconv.Quote(tag))
+	}
+	fmt.Fprintf(&b, "week: \"[[%s]]\"\n", vaultWeekName(d.date, sundayFirst))
+	fmt.Fprintf(&b, "month: \"[[%s]]\"\n", d.date.Format("2006-01"))
+	b.WriteString("---\n\n")
+	fmt.Fprintf(&b, "# %s\n\n", date)
+	for _, r := range d.records {
+		fmt.Fprintf(&b, "- %s #%s %s (%d min)\n", r.at.Format("15:04"), vaultTag(r.Tag), markdownLine(r.Content), r.Duration)
+	}
+
+	err := writeZipEntry(zw, "daily/"+date+".md", d.date, b.String())
+	return s, err
+}
+
+// writeVaultIndexes 写出周、月索引页
+func writeVaultIndexes(zw *zip.Writer, days []vaultSummary, sundayFirst bool) error {
+	type group struct {
+		name  string
+		start time.Time
+		days  []vaultSummary
+	}
+	collect := func(nameOf func(time.Time) (string, time.Time)) []*group {
+		var groups []*group
+		index := make(map[string]*group)
+		for _, d := range days {
+			name, start := nameOf(d.date)
+			if index[name] == nil {
+				index[name] = &group{name: name, start: start}
+				groups = append(groups, index[name])
+			}
+			index[name].days = append(index[name].days, d)
+		}
+		return groups
+	}
+
+	weeks := collect(func(t time.Time) (string, time.Time) {
+		return vaultWeekName(t, sundayFirst), weekStartOf(t, sundayFirst)
+	})
+	months := collect(func(t time.Time) (string, time.Time) {
+		return t.Format("2006-01"), time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	})
+
+	for _, set := range []struct {
+		dir    string
+		title  string
+		groups []*group
+	}{{"weekly", "周", weeks}, {"monthly", "月", months}} {
+		for _, g := range set.groups {
+			var b strings.Builder
+			count, minutes := 0, 0
+			tags := make(map[string]int)
+			for _, d := range g.days {
+				count += d.count
+				minutes += d.minutes
+				for tag, m := range d.tags {
+					tags[tag] += m
+				}
+			}
+
+			b.WriteString("---\n")
+			fmt.Fprintf(&b, "period: %s\nstart: %s\n", g.name, g.start.Format(utils.DateLayout))
+			fmt.Fprintf(&b, "records: %d\ntotal_minutes: %d\n", count, minutes)
+			fmt.Fprintf(&b, "total_hours: %s\n", strconv.FormatFloat(aggregate.RoundHours(minutes), 'f', -1, 64))
+			b.WriteString("---\n\n")
+			fmt.Fprintf(&b, "# %s (%s)\n\n## 标签\n\n", g.name, set.title)
+			for _, tag := range sortedTagsByMinutes(tags) {
+				fmt.Fprintf(&b, "- #%s %.1f 小时\n", vaultTag(tag), float64(tags[tag])/60.0)
+			}
+			b.WriteString("\n## 每日\n\n")
+			for _, d := range g.days {
+				fmt.Fprintf(&b, "- [[%s]] %d 条，%.1f 小时\n", d.date.Format(utils.DateLayout), d.count, float64(d.minutes)/60.0)
+			}
+			if err := writeZipEntry(zw, set.dir+"/"+g.name+".md", g.start, b.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// vaultWeekName 周索引页名称，取该周周四所在的 ISO 周，如 2026-W08
+func vaultWeekName(day time.Time, sundayFirst bool) string {
+	start := weekStartOf(day, sundayFirst)
+	thursday := start.AddDate(0, 0, 3)
+	if sundayFirst {
+		thursday = start.AddDate(0, 0, 4)
+	}
+	year, week := thursday.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// vaultTag 将标签转为 Obsidian 可识别的 #标签 (不能含空白)
+func vaultTag(tag string) string {
+	return strings.Join(strings.Fields(tag), "_")
+}
+
+// markdownLine 将内容压成一行，避免破坏列表结构
+func markdownLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// sortedTagsByMinutes 按时长从多到少排列标签
+func sortedTagsByMinutes(tags map[string]int) []string {
+	keys := make([]string, 0, len(tags))
+	for tag := range tags {
+		keys = append(keys, tag)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if tags[keys[i]] != tags[keys[j]] {
+			return tags[keys[i]] > tags[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// writeZipEntry 向压缩包写入一个文件
+func writeZipEntry(zw *zip.Writer, name string, modified time.Time, content string) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
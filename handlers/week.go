@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+)
+
+// resolveWeek 解析周统计的时间范围 (闭区间)，按以下优先级:
+//   - iso_week=2026-W07: ISO 周，用户以周日为一周开始时整体提前一天
+//   - week=current|previous: 用户时区下的本周/上周
+//   - week_start/week_end: 客户端自行计算的范围
+func resolveWeek(c *gin.Context, settings models.UserSettings) (time.Time, time.Time, error) {
+	if isoWeek := c.Query("iso_week"); isoWeek != "" {
+		monday, err := utils.ParseISOWeek(isoWeek)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("iso_week 格式不正确 (如 2026-W07)")
+		}
+		start := monday
+		if settings.SundayFirst() {
+			start = monday.AddDate(0, 0, -1)
+		}
+		return start, start.AddDate(0, 0, 6), nil
+	}
+
+	if week := c.Query("week"); week != "" {
+		start := weekStartOf(utils.Today(settingsLocation(settings)), settings.SundayFirst())
+		switch week {
+		case "current":
+		case "previous":
+			start = start.AddDate(0, 0, -7)
+		default:
+			return time.Time{}, time.Time{}, errors.New("week 仅支持 current 或 previous")
+		}
+		return start, start.AddDate(0, 0, 6), nil
+	}
+
+	weekStart, weekEnd := c.Query("week_start"), c.Query("week_end")
+	if weekStart == "" || weekEnd == "" {
+		return time.Time{}, time.Time{}, errors.New("需提供 iso_week、week 或 week_start 和 week_end")
+	}
+	start, end, err := utils.ParseDateRange(weekStart, weekEnd, 0)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("week_start 或 week_end 格式不正确")
+	}
+	return start, end, nil
+}
+
+// weekStartOf 返回 day 所在周的第一天
+func weekStartOf(day time.Time, sundayFirst bool) time.Time {
+	offset := (int(day.Weekday()) + 6) % 7
+	if sundayFirst {
+		offset = int(day.Weekday())
+	}
+	return day.AddDate(0, 0, -offset)
+}
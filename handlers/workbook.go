@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+	"github.com/user/daily-records-backend/utils"
+	"github.com/user/daily-records-backend/xlsx"
+	"go.uber.org/zap"
+)
+
+// xlsxContentType Excel 工作簿的 MIME 类型
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// ExportWorkbook 导出时间范围内的 Excel 工作簿 (参数: from、to、tz)
+func ExportWorkbook(c *gin.Context) {
+	userID := c.GetString("user_id")
+
+	from, to, loc, ok := parseExportRange(c)
+	if !ok {
+		return
+	}
+	writeWorkbook(c, userID, from, to, loc, exportFilename("records", from, to, "xlsx"))
+}
+
+// writeWorkbook 读取记录、生成工作簿并作为附件写出
+func writeWorkbook(c *gin.Context, userID string, from, to time.Time, loc *time.Location, filename string) {
+	var records []models.Record
+	err := fetchRecordsPaged(userID, from, to, loc, func(page []models.Record) error {
+		records = append(records, page...)
+		return nil
+	})
+	if err != nil {
+		utils.Error(c, 500, "查询数据失败")
+		return
+	}
+
+	wb := buildWorkbook(records, aggregate.Query{From: from, To: to, Location: loc})
+	c.Header("Content-Type", xlsxContentType)
+	setAttachment(c, filename)
+	c.Status(200)
+	if err := wb.Write(c.Writer); err != nil {
+		utils.GetLogger().Error("写出工作簿失败", zap.String("user_id", userID), zap.Error(err))
+		c.Abort()
+	}
+}
+
+// buildWorkbook 生成包含原始记录、标签汇总和月度汇总三个工作表的工作簿
+func buildWorkbook(records []models.Record, q aggregate.Query) *xlsx.Workbook {
+	wb := xlsx.New()
+
+	// 原始记录
+	sheet := wb.AddSheet("记录")
+	sheet.SetWidths(12, 18, 10, 40, 12, 10)
+	sheet.SetHeader("日期", "时间", "标签", "内容", "时长(分钟)", "小时")
+	for _, r := range records {
+		at, ok := aggregate.RecordTime(r)
+		if !ok {
+			continue
+		}
+		at = at.In(q.Location)
+		sheet.AddRow(
+			xlsx.Date(at),
+			xlsx.DateTime(at),
+			xlsx.String(r.Tag),
+			xlsx.String(r.Content),
+			xlsx.Int(r.Duration),
+			xlsx.Decimal(aggregate.RoundHours(r.Duration)),
+		)
+	}
+
+	rows := aggregate.FromRecords(records)
+
+	// 标签汇总
+	tagQ := q
+	tagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	tagRes := aggregate.Run(rows, tagQ)
+	aggregate.SortByMinutes(tagRes.Buckets)
+
+	sheet = wb.AddSheet("标签汇总")
+	sheet.SetWidths(12, 10, 12, 10, 10)
+	sheet.SetHeader("标签", "记录数", "分钟", "小时", "占比")
+	for _, b := range tagRes.Buckets {
+		sheet.AddRow(
+			xlsx.String(b.Tag),
+			xlsx.Int(b.Count),
+			xlsx.Int(b.Minutes),
+			xlsx.Decimal(b.Hours()),
+			xlsx.Percent(tagRes.Ratio(b)),
+		)
+	}
+
+	// 月度汇总: 每月一行、每个标签一列 (小时)，可直接插入图表
+	monthQ := q
+	monthQ.Granularity = aggregate.Month
+	monthQ.FillEmpty = true
+	months := aggregate.Run(rows, monthQ).Buckets
+
+	monthTagQ := monthQ
+	monthTagQ.GroupBy = []aggregate.GroupBy{aggregate.ByTag}
+	monthTagQ.FillEmpty = false
+	minutes := make(map[string]map[string]int)
+	for _, b := range aggregate.Run(rows, monthTagQ).Buckets {
+		if minutes[b.Period] == nil {
+			minutes[b.Period] = make(map[string]int)
+		}
+		minutes[b.Period][b.Tag] += b.Minutes
+	}
+
+	header := []string{"月份"}
+	widths := []float64{12}
+	for _, b := range tagRes.Buckets {
+		header = append(header, b.Tag)
+		widths = append(widths, 10)
+	}
+	header = append(header, "合计(小时)", "记录数")
+	widths = append(widths, 12, 10)
+
+	sheet = wb.AddSheet("月度")
+	sheet.SetWidths(widths...)
+	sheet.SetHeader(header...)
+	for _, m := range months {
+		cells := []xlsx.Cell{xlsx.Date(m.Start)}
+		for _, b := range tagRes.Buckets {
+			cells = append(cells, xlsx.Decimal(aggregate.RoundHours(minutes[m.Period][b.Tag])))
+		}
+		cells = append(cells, xlsx.Decimal(m.Hours()), xlsx.Int(m.Count))
+		sheet.AddRow(cells...)
+	}
+
+	return wb
+}
@@ -0,0 +1,103 @@
+// Package ics 读写 iCalendar (RFC 5545) 日历数据中的事件
+package ics
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// Event 日历事件 (VEVENT)
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Categories  []string
+	Created     time.Time
+
+	// 以下字段仅在解析时填充
+	AllDay       bool        // 全天事件 (DTSTART 为日期)
+	RecurrenceID string      // 重复事件中被单独修改的实例 (原定开始时间的 UTC 时间戳)
+	RRule        string      // 重复规则 (RRULE)，用 Expand 展开
+	ExDates      []time.Time // 重复事件中排除的发生 (EXDATE)
+	Status       string      // TENTATIVE、CONFIRMED、CANCELLED
+}
+
+// Calendar 日历
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// stampLayout UTC 时间格式，如 20260216T093000Z
+const stampLayout = "20060102T150405Z"
+
+// Write 以 iCalendar 格式写出日历
+func Write(w io.Writer, cal Calendar) error {
+	b := &lineWriter{}
+	b.line("BEGIN:VCALENDAR")
+	b.line("VERSION:2.0")
+	b.line("PRODID:-//daily-records//records//ZH")
+	b.line("CALSCALE:GREGORIAN")
+	b.line("METHOD:PUBLISH")
+	if cal.Name != "" {
+		b.line("X-WR-CALNAME:" + escape(cal.Name))
+	}
+
+	now := time.Now().UTC().Format(stampLayout)
+	for _, e := range cal.Events {
+		b.line("BEGIN:VEVENT")
+		b.line("UID:" + escape(e.UID))
+		b.line("DTSTAMP:" + now)
+		b.line("DTSTART:" + e.Start.UTC().Format(stampLayout))
+		b.line("DTEND:" + e.End.UTC().Format(stampLayout))
+		if !e.Created.IsZero() {
+			b.line("CREATED:" + e.Created.UTC().Format(stampLayout))
+		}
+		b.line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			b.line("DESCRIPTION:" + escape(e.Description))
+		}
+		if len(e.Categories) > 0 {
+			cats := make([]string, len(e.Categories))
+			for i, c := range e.Categories {
+				cats[i] = escape(c)
+			}
+			b.line("CATEGORIES:" + strings.Join(cats, ","))
+		}
+		b.line("TRANSP:TRANSPARENT")
+		b.line("END:VEVENT")
+	}
+	b.line("END:VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escape 转义 TEXT 类型的值
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	return r.Replace(s)
+}
+
+// lineWriter 按 RFC 5545 以 CRLF 换行，超过 75 字节的行折叠 (不拆分 UTF-8 字符)
+type lineWriter struct {
+	strings.Builder
+}
+
+func (b *lineWriter) line(s string) {
+	const limit = 75
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	b.WriteString("\r\n")
+}
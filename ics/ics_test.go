@@ -0,0 +1,141 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteParseRoundTrip(t *testing.T) {
+	start := time.Date(2026, 2, 16, 9, 30, 0, 0, time.UTC)
+	cal := Calendar{
+		Name: "每日记录",
+		Events: []Event{{
+			UID:         "r1@daily-records",
+			Start:       start,
+			End:         start.Add(45 * time.Minute),
+			Summary:     "读书; 笔记, 复盘",
+			Description: "第一行\n第二行 " + strings.Repeat("很长的描述", 20),
+			Categories:  []string{"学习", "a,b"},
+			Created:     start.Add(45 * time.Minute),
+		}},
+	}
+
+	var b strings.Builder
+	if err := Write(&b, cal); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("行超过 75 字节: %q", line)
+		}
+	}
+
+	got, err := Parse(strings.NewReader(b.String()), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != cal.Name || len(got.Events) != 1 {
+		t.Fatalf("got %+v", got)
+	}
+	e, want := got.Events[0], cal.Events[0]
+	if e.UID != want.UID || e.Summary != want.Summary || e.Description != want.Description {
+		t.Errorf("got %+v", e)
+	}
+	if !e.Start.Equal(want.Start) || !e.End.Equal(want.End) || !e.Created.Equal(want.Created) {
+		t.Errorf("times = %v %v %v", e.Start, e.End, e.Created)
+	}
+	if strings.Join(e.Categories, "|") != "学习|a,b" {
+		t.Errorf("Categories = %q", e.Categories)
+	}
+}
+
+func TestParse(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	content := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:a",
+		"DTSTART;TZID=Asia/Shanghai:20260301T090000",
+		"DURATION:PT1H30M",
+		"SUMMARY:晨会",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"EXDATE;TZID=Asia/Shanghai:20260309T090000,20260316T090000",
+		"BEGIN:VALARM",
+		"DESCRIPTION:提醒",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:a",
+		"RECURRENCE-ID;TZID=Asia/Shanghai:20260323T090000",
+		"DTSTART;TZID=Asia/Shanghai:20260323T100000",
+		"DTEND;TZID=Asia/Shanghai:20260323T110000",
+		"SUMMARY:晨会 (改期)",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:b",
+		"DTSTART;VALUE=DATE:20260305",
+		"SUMMARY:全天",
+		"STATUS:cancelled",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:没有开始时间",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	cal, err := Parse(strings.NewReader(content), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cal.Events) != 3 {
+		t.Fatalf("len(Events) = %d, want 3", len(cal.Events))
+	}
+
+	master := cal.Events[0]
+	if master.RRule != "FREQ=WEEKLY;BYDAY=MO" || master.Description != "" {
+		t.Errorf("master = %+v", master)
+	}
+	if !master.Start.Equal(time.Date(2026, 3, 1, 9, 0, 0, 0, shanghai)) || master.End.Sub(master.Start) != 90*time.Minute {
+		t.Errorf("master times = %v ~ %v", master.Start, master.End)
+	}
+	if len(master.ExDates) != 2 || !master.ExDates[1].Equal(time.Date(2026, 3, 16, 9, 0, 0, 0, shanghai)) {
+		t.Errorf("ExDates = %v", master.ExDates)
+	}
+
+	if override := cal.Events[1]; override.RecurrenceID != "20260323T010000Z" {
+		t.Errorf("RecurrenceID = %q, want UTC 时间戳", override.RecurrenceID)
+	}
+
+	allDay := cal.Events[2]
+	if !allDay.AllDay || allDay.Status != "CANCELLED" || allDay.End.Sub(allDay.Start) != 24*time.Hour {
+		t.Errorf("allDay = %+v", allDay)
+	}
+
+	if _, err := Parse(strings.NewReader("hello"), time.UTC); err != ErrNotCalendar {
+		t.Errorf("err = %v, want ErrNotCalendar", err)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+		ok   bool
+	}{
+		{"PT1H30M", 90 * time.Minute, true},
+		{"P1D", 24 * time.Hour, true},
+		{"P1W", 7 * 24 * time.Hour, true},
+		{"-PT15M", -15 * time.Minute, true},
+		{"P1DT2H", 26 * time.Hour, true},
+		{"1H", 0, false},
+		{"PT1X", 0, false},
+		{"PT1", 0, false},
+	}
+	for _, tt := range tests {
+		got, err := parseDuration(tt.in)
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
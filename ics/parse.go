@@ -0,0 +1,295 @@
+package ics
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotCalendar 内容不是 iCalendar 格式
+var ErrNotCalendar = errors.New("不是有效的 iCalendar 文件")
+
+// Parse 解析 iCalendar 内容中的事件
+//
+// 带 TZID 的时间按对应时区解析，无法识别的 TZID 与不带时区的浮动时间按 loc 解析。
+// 缺少 DTEND 时依次使用 DURATION、全天事件的一天、DTSTART 作为结束时间；
+// 缺少 DTSTART 的事件会被忽略。嵌套组件 (如 VALARM) 中的属性不影响事件。
+// 重复事件只记录 RRULE 与 EXDATE，需用 Expand 展开。
+func Parse(r io.Reader, loc *time.Location) (Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	var cal Calendar
+	var stack []string
+	var event *Event
+	var duration time.Duration
+	found := false
+
+	for _, raw := range lines {
+		name, params, value, ok := splitLine(raw)
+		if !ok {
+			continue
+		}
+		switch name {
+		case "BEGIN":
+			component := strings.ToUpper(value)
+			stack = append(stack, component)
+			if component == "VCALENDAR" {
+				found = true
+			}
+			if component == "VEVENT" && len(stack) == 2 {
+				event, duration = &Event{}, 0
+			}
+			continue
+		case "END":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if strings.ToUpper(value) == "VEVENT" && event != nil && len(stack) == 1 {
+				if finishEvent(event, duration) {
+					cal.Events = append(cal.Events, *event)
+				}
+				event = nil
+			}
+			continue
+		}
+
+		top := ""
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if top == "VCALENDAR" && name == "X-WR-CALNAME" {
+			cal.Name = unescape(value)
+		}
+		if top != "VEVENT" || event == nil {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			event.UID = value
+		case "SUMMARY":
+			event.Summary = unescape(value)
+		case "DESCRIPTION":
+			event.Description = unescape(value)
+		case "CATEGORIES":
+			for _, c := range splitText(value) {
+				if c = strings.TrimSpace(c); c != "" {
+					event.Categories = append(event.Categories, c)
+				}
+			}
+		case "STATUS":
+			event.Status = strings.ToUpper(value)
+		case "RRULE":
+			event.RRule = value
+		case "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				if t, _, err := parseTime(v, params, loc); err == nil {
+					event.ExDates = append(event.ExDates, t)
+				}
+			}
+		case "RECURRENCE-ID":
+			event.RecurrenceID = value
+			if t, _, err := parseTime(value, params, loc); err == nil {
+				event.RecurrenceID = t.UTC().Format(stampLayout)
+			}
+		case "DTSTART":
+			if t, allDay, err := parseTime(value, params, loc); err == nil {
+				event.Start, event.AllDay = t, allDay
+			}
+		case "DTEND":
+			if t, _, err := parseTime(value, params, loc); err == nil {
+				event.End = t
+			}
+		case "DURATION":
+			if d, err := parseDuration(value); err == nil {
+				duration = d
+			}
+		case "CREATED":
+			if t, _, err := parseTime(value, params, loc); err == nil {
+				event.Created = t
+			}
+		}
+	}
+
+	if !found {
+		return Calendar{}, ErrNotCalendar
+	}
+	return cal, nil
+}
+
+// finishEvent 补全结束时间，缺少开始时间的事件返回 false
+func finishEvent(e *Event, duration time.Duration) bool {
+	if e.Start.IsZero() {
+		return false
+	}
+	if e.End.IsZero() {
+		switch {
+		case duration > 0:
+			e.End = e.Start.Add(duration)
+		case e.AllDay:
+			e.End = e.Start.AddDate(0, 0, 1)
+		default:
+			e.End = e.Start
+		}
+	}
+	return true
+}
+
+// unfold 读取内容行并展开折叠行 (以空格或制表符开头的行接续上一行)
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取日历失败: %w", err)
+	}
+	return lines, nil
+}
+
+// splitLine 拆分内容行 NAME;PARAM=VALUE:value，参数值可用双引号包含冒号
+func splitLine(line string) (string, map[string]string, string, bool) {
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[colon+1:], true
+}
+
+// parseTime 解析 DATE 或 DATE-TIME 值，返回是否为日期 (全天)
+func parseTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(stampLayout, value)
+		return t, false, err
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseDuration 解析 DURATION 值，如 PT1H30M、P1D、P1W
+func parseDuration(value string) (time.Duration, error) {
+	s := strings.TrimPrefix(value, "+")
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if !strings.HasPrefix(s, "P") {
+		return 0, fmt.Errorf("无法识别的时长 %q", value)
+	}
+	s = s[1:]
+
+	var d time.Duration
+	inTime := false
+	num := ""
+	for _, r := range s {
+		switch {
+		case r == 'T':
+			inTime = true
+		case r >= '0' && r <= '9':
+			num += string(r)
+		default:
+			n, err := strconv.Atoi(num)
+			if err != nil {
+				return 0, fmt.Errorf("无法识别的时长 %q", value)
+			}
+			unit := map[rune]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour}
+			if inTime {
+				unit = map[rune]time.Duration{'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+			}
+			u, ok := unit[r]
+			if !ok {
+				return 0, fmt.Errorf("无法识别的时长 %q", value)
+			}
+			d += time.Duration(n) * u
+			num = ""
+		}
+	}
+	if num != "" {
+		return 0, fmt.Errorf("无法识别的时长 %q", value)
+	}
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
+
+// splitText 按未转义的逗号拆分多值 TEXT 属性并反转义
+func splitText(value string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, unescape(value[start:i]))
+			start = i + 1
+		}
+	}
+	return append(values, unescape(value[start:]))
+}
+
+// unescape 反转义 TEXT 类型的值
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
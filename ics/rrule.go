@@ -0,0 +1,189 @@
+package ics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxExpandSteps 展开单个重复事件时最多推算的次数 (含范围之前的发生)
+const maxExpandSteps = 100000
+
+// ErrTooManyOccurrences 重复事件在推算上限内没有结束
+var ErrTooManyOccurrences = errors.New("重复事件发生次数过多")
+
+// rule 解析后的 RRULE
+type rule struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+}
+
+// Expand 展开重复事件在 [start, end) 内的发生
+//
+// 支持 FREQ=DAILY/WEEKLY/MONTHLY/YEARLY 以及 INTERVAL、COUNT、UNTIL、BYDAY (仅 WEEKLY，
+// 不带序号)，WKST 按周一处理；其余规则返回错误。按月、按年重复时，目标月份没有对应日期
+// (如 31 日、2 月 29 日) 的发生按 RFC 5545 跳过。EXDATE 中的时间不会生成。
+//
+// 每个发生的 RecurrenceID 为其开始时间的 UTC 时间戳，与 Parse 对单独修改实例的
+// RECURRENCE-ID 取值一致。不含 RRULE 的事件原样返回 (在范围内时)。
+func Expand(e Event, start, end time.Time) ([]Event, error) {
+	if e.RRule == "" {
+		if e.Start.Before(start) || !e.Start.Before(end) {
+			return nil, nil
+		}
+		return []Event{e}, nil
+	}
+
+	r, err := parseRule(e.RRule, e.Start.Location())
+	if err != nil {
+		return nil, err
+	}
+	length := e.End.Sub(e.Start)
+
+	var events []Event
+	generated := 0
+	for step := 0; ; step++ {
+		if step >= maxExpandSteps {
+			return nil, ErrTooManyOccurrences
+		}
+		for _, t := range r.occurrences(e.Start, step) {
+			if t.Before(e.Start) {
+				continue
+			}
+			if r.count > 0 && generated >= r.count || !r.until.IsZero() && t.After(r.until) || !t.Before(end) {
+				return events, nil
+			}
+			generated++
+			if t.Before(start) || excluded(e.ExDates, t) {
+				continue
+			}
+			occurrence := e
+			occurrence.Start, occurrence.End = t, t.Add(length)
+			occurrence.RRule, occurrence.ExDates = "", nil
+			occurrence.RecurrenceID = t.UTC().Format(stampLayout)
+			events = append(events, occurrence)
+		}
+	}
+}
+
+// occurrences 返回第 step 个周期内的发生时间 (按时间顺序，不同月份天数不足时可能为空)
+func (r rule) occurrences(first time.Time, step int) []time.Time {
+	n := step * r.interval
+	y, m, d := first.Date()
+	hh, mm, ss := first.Clock()
+	loc := first.Location()
+
+	switch r.freq {
+	case "DAILY":
+		return []time.Time{first.AddDate(0, 0, n)}
+	case "WEEKLY":
+		if len(r.byDay) == 0 {
+			return []time.Time{first.AddDate(0, 0, 7*n)}
+		}
+		monday := first.AddDate(0, 0, 7*n-(int(first.Weekday())+6)%7)
+		times := make([]time.Time, 0, len(r.byDay))
+		for _, wd := range r.byDay {
+			times = append(times, monday.AddDate(0, 0, (int(wd)+6)%7))
+		}
+		return times
+	case "MONTHLY":
+		t := time.Date(y, m+time.Month(n), d, hh, mm, ss, first.Nanosecond(), loc)
+		if t.Day() != d {
+			return nil
+		}
+		return []time.Time{t}
+	default: // YEARLY
+		t := time.Date(y+n, m, d, hh, mm, ss, first.Nanosecond(), loc)
+		if t.Day() != d {
+			return nil
+		}
+		return []time.Time{t}
+	}
+}
+
+// parseRule 解析 RRULE 值，如 FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;UNTIL=20261231T000000Z
+func parseRule(value string, loc *time.Location) (rule, error) {
+	r := rule{interval: 1}
+	var byDay string
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.freq = strings.ToUpper(v)
+		case "INTERVAL":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return rule{}, fmt.Errorf("无法识别的重复间隔 %q", v)
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				return rule{}, fmt.Errorf("无法识别的重复次数 %q", v)
+			}
+			r.count = n
+		case "UNTIL":
+			t, allDay, err := parseTime(v, nil, loc)
+			if err != nil {
+				return rule{}, fmt.Errorf("无法识别的重复截止时间 %q", v)
+			}
+			if allDay {
+				t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+			}
+			r.until = t
+		case "BYDAY":
+			byDay = strings.ToUpper(v)
+		case "WKST":
+		default:
+			return rule{}, fmt.Errorf("不支持的重复规则 %s", k)
+		}
+	}
+
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rule{}, fmt.Errorf("不支持的重复频率 %q", r.freq)
+	}
+	if byDay == "" {
+		return r, nil
+	}
+	if r.freq != "WEEKLY" {
+		return rule{}, errors.New("BYDAY 仅支持按周重复")
+	}
+	weekdays := map[string]time.Weekday{
+		"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+		"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+	}
+	seen := make(map[time.Weekday]bool)
+	for _, s := range strings.Split(byDay, ",") {
+		wd, ok := weekdays[s]
+		if !ok {
+			return rule{}, fmt.Errorf("不支持的 BYDAY 取值 %q", s)
+		}
+		if !seen[wd] {
+			seen[wd] = true
+			r.byDay = append(r.byDay, wd)
+		}
+	}
+	// 按周一开始的顺序排列，保证同一周内的发生按时间先后
+	sort.Slice(r.byDay, func(i, j int) bool {
+		return (int(r.byDay[i])+6)%7 < (int(r.byDay[j])+6)%7
+	})
+	return r, nil
+}
+
+// excluded 判断 t 是否在 EXDATE 中
+func excluded(exDates []time.Time, t time.Time) bool {
+	for _, x := range exDates {
+		if x.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
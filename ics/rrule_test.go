@@ -0,0 +1,144 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	at := func(s string) time.Time {
+		t, err := time.ParseInLocation("2006-01-02 15:04", s, shanghai)
+		if err != nil {
+			panic(err)
+		}
+		return t
+	}
+
+	tests := []struct {
+		name       string
+		start      string
+		rrule      string
+		exDates    []string
+		from, to   string
+		want       []string
+		wantErrSub string
+	}{
+		{
+			name: "每天，计数", start: "2026-03-01 09:00", rrule: "FREQ=DAILY;COUNT=3",
+			from: "2026-02-01 00:00", to: "2026-04-01 00:00",
+			want: []string{"2026-03-01 09:00", "2026-03-02 09:00", "2026-03-03 09:00"},
+		},
+		{
+			name: "计数包含范围之前的发生", start: "2026-03-01 09:00", rrule: "FREQ=DAILY;COUNT=3",
+			from: "2026-03-02 00:00", to: "2026-04-01 00:00",
+			want: []string{"2026-03-02 09:00", "2026-03-03 09:00"},
+		},
+		{
+			name: "隔周一三，截止日期", start: "2026-03-02 19:30", rrule: "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE,MO;UNTIL=20260318",
+			from: "2026-03-01 00:00", to: "2026-04-30 00:00",
+			want: []string{"2026-03-02 19:30", "2026-03-04 19:30", "2026-03-16 19:30", "2026-03-18 19:30"},
+		},
+		{
+			name: "按周且首次不在 BYDAY 上", start: "2026-03-04 08:00", rrule: "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3",
+			from: "2026-03-01 00:00", to: "2026-04-30 00:00",
+			want: []string{"2026-03-04 08:00", "2026-03-09 08:00", "2026-03-11 08:00"},
+		},
+		{
+			name: "每月 31 日跳过小月", start: "2026-01-31 10:00", rrule: "FREQ=MONTHLY;COUNT=4",
+			from: "2026-01-01 00:00", to: "2026-12-31 00:00",
+			want: []string{"2026-01-31 10:00", "2026-03-31 10:00", "2026-05-31 10:00", "2026-07-31 10:00"},
+		},
+		{
+			name: "每年 2 月 29 日", start: "2024-02-29 10:00", rrule: "FREQ=YEARLY",
+			from: "2024-01-01 00:00", to: "2029-01-01 00:00",
+			want: []string{"2024-02-29 10:00", "2028-02-29 10:00"},
+		},
+		{
+			name: "排除日期", start: "2026-03-01 09:00", rrule: "FREQ=DAILY;COUNT=3", exDates: []string{"2026-03-02 09:00"},
+			from: "2026-03-01 00:00", to: "2026-04-01 00:00",
+			want: []string{"2026-03-01 09:00", "2026-03-03 09:00"},
+		},
+		{
+			name: "无限重复受范围限制", start: "2020-01-01 07:00", rrule: "FREQ=DAILY",
+			from: "2026-03-01 00:00", to: "2026-03-03 00:00",
+			want: []string{"2026-03-01 07:00", "2026-03-02 07:00"},
+		},
+		{
+			name: "不支持的规则", start: "2026-03-01 09:00", rrule: "FREQ=MONTHLY;BYMONTHDAY=1",
+			from: "2026-03-01 00:00", to: "2026-04-01 00:00", wantErrSub: "BYMONTHDAY",
+		},
+		{
+			name: "带序号的 BYDAY", start: "2026-03-01 09:00", rrule: "FREQ=WEEKLY;BYDAY=1MO",
+			from: "2026-03-01 00:00", to: "2026-04-01 00:00", wantErrSub: "1MO",
+		},
+		{
+			name: "按小时重复", start: "2026-03-01 09:00", rrule: "FREQ=HOURLY",
+			from: "2026-03-01 00:00", to: "2026-04-01 00:00", wantErrSub: "HOURLY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := at(tt.start)
+			e := Event{UID: "u1", Start: start, End: start.Add(30 * time.Minute), Summary: "晨跑", RRule: tt.rrule}
+			for _, s := range tt.exDates {
+				e.ExDates = append(e.ExDates, at(s))
+			}
+
+			got, err := Expand(e, at(tt.from), at(tt.to))
+			if tt.wantErrSub != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrSub) {
+					t.Fatalf("err = %v, want containing %q", err, tt.wantErrSub)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d occurrences, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, o := range got {
+				if s := o.Start.In(shanghai).Format("2006-01-02 15:04"); s != tt.want[i] {
+					t.Errorf("occurrence %d = %s, want %s", i, s, tt.want[i])
+				}
+				if o.End.Sub(o.Start) != 30*time.Minute || o.RRule != "" || o.Summary != "晨跑" {
+					t.Errorf("occurrence %d = %+v", i, o)
+				}
+				if o.RecurrenceID != o.Start.UTC().Format(stampLayout) {
+					t.Errorf("occurrence %d RecurrenceID = %s", i, o.RecurrenceID)
+				}
+			}
+		})
+	}
+}
+
+func TestExpandDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("缺少时区数据")
+	}
+	start := time.Date(2026, 3, 7, 9, 0, 0, 0, ny)
+	e := Event{UID: "u1", Start: start, End: start.Add(time.Hour), RRule: "FREQ=DAILY;COUNT=2"}
+	got, err := Expand(e, start, start.AddDate(0, 0, 7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 夏令时切换后仍为当地 9 点
+	if len(got) != 2 || got[1].Start.Hour() != 9 || got[1].Start.Sub(got[0].Start) != 23*time.Hour {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestExpandNonRecurring(t *testing.T) {
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	e := Event{UID: "u1", Start: start, End: start.Add(time.Hour)}
+	if got, _ := Expand(e, start, start.Add(time.Hour)); len(got) != 1 {
+		t.Errorf("范围内的普通事件应原样返回，得到 %v", got)
+	}
+	if got, _ := Expand(e, start.Add(time.Minute), start.Add(time.Hour)); len(got) != 0 {
+		t.Errorf("范围外的普通事件不应返回，得到 %v", got)
+	}
+}
@@ -0,0 +1,113 @@
+// Package insights 将用户近期数据与其自身历史基线比较，发现值得提醒的变化
+package insights
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+)
+
+const (
+	// RecentDays 近期窗口 (含今天)
+	RecentDays = 7
+	// BaselineWeeks 基线窗口，紧接近期窗口之前的周数
+	BaselineWeeks = 8
+	// LookbackDays 检测所需的全部历史天数
+	LookbackDays = RecentDays + BaselineWeeks*7
+
+	inactiveMinDays       = 7   // 至少这么多天没有记录才提醒
+	inactiveMinActiveDays = 4   // 基线内至少有这么多天记录过该标签，才认为是习惯
+	spikeRatio            = 2.0 // 近期达到基线的倍数视为激增
+	dropRatio             = 0.4 // 近期低于基线的倍数视为骤降
+	minBaselineMinutes    = 60  // 基线周均时长过小时不判断激增/骤降
+)
+
+// tagHistory 单个标签在检测窗口内的数据
+type tagHistory struct {
+	lastActive    time.Time
+	activeDays    int // 基线窗口内有记录的天数
+	recentMinutes int
+	baseMinutes   int
+	hasRecent     bool
+}
+
+// Detect 对截至 today 的数据进行检测，rows 需覆盖 [today-LookbackDays+1, today]
+func Detect(rows []aggregate.Row, today time.Time, loc *time.Location) []models.Insight {
+	from := today.AddDate(0, 0, -(LookbackDays - 1))
+	recentFrom := today.AddDate(0, 0, -(RecentDays - 1))
+
+	q := aggregate.Query{
+		From:        from,
+		To:          today,
+		Location:    loc,
+		Granularity: aggregate.Day,
+		GroupBy:     []aggregate.GroupBy{aggregate.ByTag},
+	}
+
+	history := make(map[string]*tagHistory)
+	for _, b := range aggregate.Run(rows, q).Buckets {
+		day := time.Date(b.Start.Year(), b.Start.Month(), b.Start.Day(), 0, 0, 0, 0, time.UTC)
+		h, ok := history[b.Tag]
+		if !ok {
+			h = &tagHistory{}
+			history[b.Tag] = h
+		}
+		if day.After(h.lastActive) {
+			h.lastActive = day
+		}
+		if day.Before(recentFrom) {
+			h.activeDays++
+			h.baseMinutes += b.Minutes
+		} else {
+			h.hasRecent = true
+			h.recentMinutes += b.Minutes
+		}
+	}
+
+	insights := make([]models.Insight, 0)
+	for tag, h := range history {
+		// 长时间未记录
+		if !h.hasRecent && h.activeDays >= inactiveMinActiveDays {
+			days := int(today.Sub(h.lastActive).Hours() / 24)
+			if days >= inactiveMinDays {
+				insights = append(insights, models.Insight{
+					Type:    models.InsightInactive,
+					Tag:     tag,
+					Days:    days,
+					Message: fmt.Sprintf("你已经 %d 天没有记录「%s」了", days, tag),
+				})
+			}
+			continue
+		}
+
+		// 近 7 天与基线周均对比
+		baseline := float64(h.baseMinutes) / BaselineWeeks
+		if baseline < minBaselineMinutes {
+			continue
+		}
+		ratio := aggregate.Round(float64(h.recentMinutes)/baseline, 2)
+		insight := models.Insight{Tag: tag, Ratio: ratio, Current: h.recentMinutes, Baseline: aggregate.Round(baseline, 2)}
+		switch {
+		case ratio >= spikeRatio:
+			insight.Type = models.InsightSpike
+			insight.Message = fmt.Sprintf("最近 7 天的「%s」是平时的 %.1f 倍", tag, ratio)
+		case ratio <= dropRatio:
+			insight.Type = models.InsightDrop
+			insight.Message = fmt.Sprintf("最近 7 天的「%s」只有平时的 %.0f%%", tag, ratio*100)
+		default:
+			continue
+		}
+		insights = append(insights, insight)
+	}
+
+	sort.Slice(insights, func(i, j int) bool {
+		if insights[i].Type != insights[j].Type {
+			return insights[i].Type < insights[j].Type
+		}
+		return insights[i].Tag < insights[j].Tag
+	})
+	return insights
+}
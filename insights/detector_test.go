@@ -0,0 +1,61 @@
+package insights
+
+import (
+	"testing"
+	"time"
+
+	"github.com/user/daily-records-backend/aggregate"
+	"github.com/user/daily-records-backend/models"
+)
+
+func TestDetect(t *testing.T) {
+	today := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	from := today.AddDate(0, 0, -(LookbackDays - 1))
+	at := func(day time.Time) time.Time { return day.Add(10 * time.Hour) }
+
+	var rows []aggregate.Row
+	for w := 0; w < BaselineWeeks; w++ {
+		day := at(from.AddDate(0, 0, 7*w))
+		rows = append(rows,
+			aggregate.Row{Time: day, Tag: "家务", Count: 1, Minutes: 30},
+			aggregate.Row{Time: day, Tag: "工作", Count: 1, Minutes: 120},
+			aggregate.Row{Time: day, Tag: "学习", Count: 1, Minutes: 120},
+			aggregate.Row{Time: day, Tag: "休闲", Count: 1, Minutes: 30},
+		)
+	}
+	rows = append(rows,
+		aggregate.Row{Time: at(today.AddDate(0, 0, -1)), Tag: "工作", Count: 2, Minutes: 300},
+		aggregate.Row{Time: at(today.AddDate(0, 0, -2)), Tag: "学习", Count: 1, Minutes: 30},
+		aggregate.Row{Time: at(today), Tag: "休闲", Count: 3, Minutes: 300},
+		// 检测窗口之前的数据不参与
+		aggregate.Row{Time: at(from.AddDate(0, 0, -1)), Tag: "其他", Count: 9, Minutes: 999},
+	)
+
+	got := Detect(rows, today, time.UTC)
+	want := []models.Insight{
+		{Type: models.InsightDrop, Tag: "学习", Ratio: 0.25, Current: 30, Baseline: 120},
+		{Type: models.InsightInactive, Tag: "家务", Days: 13},
+		{Type: models.InsightSpike, Tag: "工作", Ratio: 2.5, Current: 300, Baseline: 120},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Message == "" {
+			t.Errorf("insight %d 缺少提示文字", i)
+		}
+		g.Message = ""
+		if g != w {
+			t.Errorf("insight %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestDetectNoHistory(t *testing.T) {
+	today := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	rows := []aggregate.Row{{Time: today.Add(time.Hour), Tag: "工作", Count: 1, Minutes: 600}}
+	if got := Detect(rows, today, time.UTC); len(got) != 0 {
+		t.Errorf("没有基线时不应产生洞察，得到 %+v", got)
+	}
+}
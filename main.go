@@ -20,6 +20,9 @@ func main() {
 	// 初始化 Supabase
 	utils.InitSupabase()
 
+	// 后台定期刷新用户洞察 (缓存有效期 1 小时)
+	handlers.StartInsightsJob(30 * time.Minute)
+
 	r := gin.New() // 使用 New 而不是 Default，以自定义中间件
 
 	// 2. 日志中间件
@@ -42,9 +45,9 @@ func main() {
 	// 1. 跨域配置 (CORS)
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // 允许所有来源
-		AllowMethods:     []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "X-Week-Start", "X-Week-End", "Content-Disposition"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -54,6 +57,9 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// 日历订阅 (以地址中的订阅令牌鉴权，日历应用无法携带 Bearer 令牌)
+	r.GET("/feed/:token", handlers.GetCalendarFeed)
+
 	// 业务接口组
 	api := r.Group("/api")
 
@@ -71,6 +77,8 @@ func main() {
 		{
 			records.POST("/add", handlers.AddRecord)
 			records.POST("/batch-add", handlers.BatchAddRecords)
+			records.POST("/import", handlers.ImportRecords)
+			records.POST("/import/ics", handlers.ImportCalendar)
 			records.GET("/today", handlers.GetTodayRecords)
 			records.GET("/date", handlers.GetDateRecords)
 			records.DELETE("/delete/:id", handlers.DeleteRecord)
@@ -85,11 +93,70 @@ func main() {
 			stat.GET("/export/year", handlers.ExportYear)
 		}
 
+		// 数据导出
+		export := api.Group("/export")
+		{
+			export.GET("/records.csv", handlers.ExportRecordsCSV)
+			export.GET("/stats.csv", handlers.ExportStatsCSV)
+			export.GET("/workbook.xlsx", handlers.ExportWorkbook)
+			export.GET("/vault.zip", handlers.ExportVault)
+			export.GET("/records.ics", handlers.ExportICS)
+		}
+
+		// 服务端渲染的图表 (SVG/PNG)
+		charts := api.Group("/charts")
+		{
+			charts.GET("/trend", handlers.GetTrendChart)
+			charts.GET("/tags", handlers.GetTagPieChart)
+			charts.GET("/heatmap", handlers.GetHeatmapChart)
+		}
+
+		// 自定义导出模板
+		templates := api.Group("/templates")
+		{
+			templates.GET("", handlers.ListTemplates)
+			templates.POST("", handlers.CreateTemplate)
+			templates.POST("/preview", handlers.PreviewTemplate)
+			templates.PUT("/:id", handlers.UpdateTemplate)
+			templates.DELETE("/:id", handlers.DeleteTemplate)
+		}
+
+		// 日历订阅令牌
+		api.GET("/feed-token", handlers.GetFeedToken)
+		api.POST("/feed-token", handlers.CreateFeedToken)
+		api.DELETE("/feed-token", handlers.RevokeFeedToken)
+
+		// 账户数据导出与恢复 (数据迁移)
+		account := api.Group("/account")
+		{
+			account.POST("/export", handlers.StartAccountExport)
+			account.GET("/export/:id", handlers.GetAccountExport)
+			account.GET("/export/:id/download", handlers.DownloadAccountExport)
+			account.POST("/restore", handlers.RestoreAccount)
+		}
+
+		// 用户设置
+		api.GET("/settings", handlers.GetSettings)
+		api.POST("/settings", handlers.UpdateSettings)
+
+		// 洞察 (异常与未记录提醒)
+		api.GET("/insights", handlers.GetInsights)
+
 		// 增强版统计 (新增)
 		stats := api.Group("/stats")
 		{
 			stats.GET("/yearly", handlers.GetYearlyStats)
 			stats.GET("/monthly", handlers.GetMonthlyStats)
+			stats.GET("/heatmap", handlers.GetHeatmap)
+			stats.GET("/compare", handlers.GetComparison)
+			stats.GET("/distribution", handlers.GetDistribution)
+			stats.GET("/query", handlers.QueryStats)
+			stats.GET("/range", handlers.GetRangeStats)
+			stats.GET("/trend", handlers.GetTrend)
+			stats.GET("/durations", handlers.GetDurationStats)
+			stats.GET("/transitions", handlers.GetTransitions)
+			stats.GET("/balance", handlers.GetBalance)
+			stats.GET("/review", handlers.GetYearReview)
 		}
 	}
 
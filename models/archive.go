@@ -0,0 +1,72 @@
+package models
+
+// 账户数据归档的格式标识与版本，结构不兼容地变化时递增版本
+const (
+	ArchiveFormat  = "daily-records-archive"
+	ArchiveVersion = 1
+)
+
+// 账户导出任务状态
+const (
+	ExportJobPending = "pending"
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"
+	ExportJobFailed  = "failed"
+)
+
+// ArchiveManifest 归档中的 manifest.json
+type ArchiveManifest struct {
+	Format     string         `json:"format"`
+	Version    int            `json:"version"`
+	ExportedAt string         `json:"exported_at"`
+	Counts     map[string]int `json:"counts"` // 各文件中的条目数
+}
+
+// ArchiveSettings 归档中的 settings.json (生活平衡目标单独存于 goals.json)
+type ArchiveSettings struct {
+	WeekStart string `json:"week_start"`
+	Timezone  string `json:"timezone"`
+}
+
+// ArchiveTag 归档中的 tags.json: 标签及其使用情况
+type ArchiveTag struct {
+	Tag     string `json:"tag"`
+	Records int    `json:"records"`
+	Minutes int    `json:"minutes"`
+}
+
+// ExportJob 账户数据导出任务
+type ExportJob struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	CreatedAt    string `json:"created_at"`
+	FinishedAt   string `json:"finished_at,omitempty"`
+	Size         int64  `json:"size,omitempty"` // 归档字节数
+	Error        string `json:"error,omitempty"`
+	DownloadPath string `json:"download_path,omitempty"` // 完成后的下载地址
+	ExpiresAt    string `json:"expires_at,omitempty"`    // 归档保留到该时间
+}
+
+// RestoreCount 恢复某类数据的结果
+type RestoreCount struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"` // conflict=replace 时覆盖的条数
+	Skipped int `json:"skipped"` // conflict=skip 时保留原数据的条数
+}
+
+// RestoreError 归档中某一条目的错误，Index 从 1 开始
+type RestoreError struct {
+	File    string `json:"file"`
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// RestoreResult 恢复结果
+type RestoreResult struct {
+	DryRun    bool           `json:"dry_run"`
+	Conflict  string         `json:"conflict"` // skip 或 replace
+	Records   RestoreCount   `json:"records"`
+	Templates RestoreCount   `json:"templates"`
+	Settings  RestoreCount   `json:"settings"` // 含生活平衡目标
+	Errors    []RestoreError `json:"errors"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// FeedToken 日历订阅令牌，只保存令牌的 SHA-256 摘要
+type FeedToken struct {
+	UserID    string `json:"user_id"`
+	TokenHash string `json:"token_hash"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// FeedTokenStatus 订阅令牌状态 (不含令牌本身，令牌只在生成时返回一次)
+type FeedTokenStatus struct {
+	Active    bool   `json:"active"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// FeedTokenResponse 新生成的订阅令牌
+type FeedTokenResponse struct {
+	Token     string `json:"token"`
+	FeedPath  string `json:"feed_path"` // 订阅地址路径，如 /feed/<token>.ics
+	CreatedAt string `json:"created_at"`
+}
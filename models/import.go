@@ -0,0 +1,56 @@
+package models
+
+// 时长列的单位
+const (
+	DurationMinutes = "minutes"
+	DurationHours   = "hours"
+	DurationSeconds = "seconds"
+	DurationClock   = "clock" // h:mm 或 h:mm:ss，如 Toggl 导出的 01:30:00
+)
+
+// ImportMapping 导入文件的列映射，值为源文件中的列名 (CSV 表头或 JSON 字段名)
+//
+// 开始时间必填；时长列与结束时间列至少提供一个，同时提供时以时长列为准。
+// 日期和时间分成两列的 (如 Toggl 的 Start date / Start time) 可分别填写 start 与 start_time。
+type ImportMapping struct {
+	Content      string `json:"content" binding:"required"`
+	Tag          string `json:"tag"`
+	Start        string `json:"start" binding:"required"`
+	StartTime    string `json:"start_time"`
+	End          string `json:"end"`
+	EndTime      string `json:"end_time"`
+	Duration     string `json:"duration"`
+	DurationUnit string `json:"duration_unit" binding:"omitempty,oneof=minutes hours seconds clock"`
+
+	// TagMap 源标签 -> 本应用标签 (如项目名映射为 "工作")，未映射的值按原值校验
+	TagMap map[string]string `json:"tag_map"`
+	// DefaultTag 标签列为空或未指定时使用的标签，默认 "其他"
+	DefaultTag string `json:"default_tag"`
+	// Timezone 不带时区的时间按此时区解析，默认使用 DEFAULT_TIMEZONE 或 UTC
+	Timezone string `json:"timezone"`
+}
+
+// ImportRowError 某一行的错误，Row 为数据行序号 (从 1 开始，不含 CSV 表头)
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`    // 数据行数
+	Valid    int              `json:"valid"`    // 通过校验的行数
+	Imported int              `json:"imported"` // 实际写入的记录数 (试运行时为 0)
+	Skipped  int              `json:"skipped"`  // 已导入过或无需导入而跳过的行数
+	Errors   []ImportRowError `json:"errors"`
+	Preview  []Record         `json:"preview"` // 前若干条转换结果
+}
+
+// CalendarTagRule 日历事件 -> 标签的映射规则，按顺序匹配，第一条命中的规则生效
+type CalendarTagRule struct {
+	// Field 匹配的字段: summary (默认)、description、categories
+	Field    string `json:"field" binding:"omitempty,oneof=summary description categories"`
+	Contains string `json:"contains" binding:"required"` // 包含该文本即命中 (不区分大小写)
+	Tag      string `json:"tag" binding:"required"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// 洞察类型
+const (
+	InsightInactive = "inactive" // 某标签长时间没有记录
+	InsightSpike    = "spike"    // 近期时长明显高于平时
+	InsightDrop     = "drop"     // 近期时长明显低于平时
+)
+
+// Insight 基于用户自身历史数据发现的异常或提醒
+type Insight struct {
+	Type     string  `json:"type"`
+	Tag      string  `json:"tag"`
+	Message  string  `json:"message"`
+	Days     int     `json:"days,omitempty"`     // inactive: 距上次记录的天数
+	Ratio    float64 `json:"ratio,omitempty"`    // spike/drop: 近期与基线的倍数
+	Current  int     `json:"current,omitempty"`  // spike/drop: 近期时长 (分钟)
+	Baseline float64 `json:"baseline,omitempty"` // spike/drop: 基线时长 (分钟)
+}
+
+// InsightsResponse 洞察接口返回
+type InsightsResponse struct {
+	GeneratedAt string    `json:"generated_at"`
+	Insights    []Insight `json:"insights"`
+}
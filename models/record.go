@@ -7,13 +7,19 @@ type Record struct {
 	Content   string `json:"content" binding:"required,max=50"`
 	Tag       string `json:"tag" binding:"required"`
 	Duration  int    `json:"duration" binding:"min=0"`
+	StartedAt string `json:"started_at,omitempty"` // 行动开始时间 (可选)
 	CreatedAt string `json:"created_at"`
+
+	// ExternalID 外部来源标识 (如日历事件 UID)，用于重复导入时去重
+	ExternalID string `json:"external_id,omitempty"`
 }
 
+// AllowedTags 可用的标签
+var AllowedTags = []string{"工作", "学习", "休闲", "家务", "其他"}
+
 // ValidateTag 验证标签并返回合法的标签
 func ValidateTag(tag string) string {
-	allowedTags := []string{"工作", "学习", "休闲", "家务", "其他"}
-	for _, t := range allowedTags {
+	for _, t := range AllowedTags {
 		if tag == t {
 			return tag
 		}
@@ -28,6 +34,15 @@ type WeekStat struct {
 	TotalHours float64 `json:"total_hours"`
 }
 
+// WeekStatResponse 周统计返回，包含实际使用的时间范围
+type WeekStatResponse struct {
+	PeriodStatus string     `json:"period_status"`
+	WeekStart    string     `json:"week_start"`
+	WeekEnd      string     `json:"week_end"`
+	WeekStartsOn string     `json:"week_starts_on"` // monday 或 sunday
+	TagStats     []WeekStat `json:"tag_stats"`
+}
+
 // YearTagStat 年标签统计
 type YearTagStat struct {
 	Tag        string  `json:"tag"`
@@ -44,10 +59,15 @@ type MonthHour struct {
 
 // YearStat 年度统计结构体
 type YearStat struct {
-	TagStats   []YearTagStat `json:"tag_stats"`
-	MonthHours []MonthHour   `json:"month_hours"`
-	MaxMonth   int           `json:"max_month"`
-	MaxHours   float64       `json:"max_hours"`
-	MinMonth   int           `json:"min_month"`
-	MinHours   float64       `json:"min_hours"`
+	PeriodStatus string        `json:"period_status"`
+	TagStats     []YearTagStat `json:"tag_stats"`
+	MonthHours   []MonthHour   `json:"month_hours"`
+	MaxMonth     int           `json:"max_month"`
+	MaxHours     float64       `json:"max_hours"`
+	MinMonth     int           `json:"min_month"`
+	MinHours     float64       `json:"min_hours"`
+
+	// 生活平衡 (仅在用户设置了目标分配时返回)
+	Balance        *BalanceScore  `json:"balance,omitempty"`
+	MonthlyBalance []BalanceScore `json:"monthly_balance,omitempty"`
 }
@@ -0,0 +1,60 @@
+package models
+
+// YearReview 年度回顾
+type YearReview struct {
+	PeriodStatus       string          `json:"period_status"`
+	Year               int             `json:"year"`
+	TotalRecords       int             `json:"total_records"`
+	TotalMinutes       int             `json:"total_minutes"`
+	TotalHours         float64         `json:"total_hours"`
+	ActiveDays         int             `json:"active_days"`
+	TopTags            []RangeTagStat  `json:"top_tags"`
+	LongestStreak      Streak          `json:"longest_streak"`
+	BusiestDay         *PeriodTotal    `json:"busiest_day"`
+	BusiestWeek        *PeriodTotal    `json:"busiest_week"`
+	BusiestMonth       *PeriodTotal    `json:"busiest_month"`
+	Heatmap            HeatmapSummary  `json:"heatmap"`
+	FirstActivity      *Record         `json:"first_activity"`
+	FrequentActivities []ActivityCount `json:"frequent_activities"`
+	PreviousYear       YearOverYear    `json:"previous_year"`
+	Balance            *BalanceScore   `json:"balance,omitempty"`
+}
+
+// Streak 连续有记录的天数
+type Streak struct {
+	Days  int    `json:"days"`
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// PeriodTotal 某个周期 (日/周/月) 的合计
+type PeriodTotal struct {
+	Period  string `json:"period"` // 2026-02-21 / 2026-W08 / 2026-02
+	Count   int    `json:"count"`
+	Minutes int    `json:"minutes"`
+}
+
+// HeatmapSummary 年度热力图概要
+type HeatmapSummary struct {
+	ActiveDays int    `json:"active_days"`
+	MaxMinutes int    `json:"max_minutes"`
+	Levels     [5]int `json:"levels"` // 各活跃等级 (0-4) 的天数
+}
+
+// ActivityCount 相同内容的行动出现次数
+type ActivityCount struct {
+	Content string `json:"content"`
+	Tag     string `json:"tag"`
+	Count   int    `json:"count"`
+	Minutes int    `json:"minutes"`
+}
+
+// YearOverYear 与上一年的对比
+type YearOverYear struct {
+	Year         int        `json:"year"`
+	TotalRecords int        `json:"total_records"`
+	TotalMinutes int        `json:"total_minutes"`
+	DeltaMinutes int        `json:"delta_minutes"`
+	DeltaPercent *float64   `json:"delta_percent"`
+	TagDeltas    []TagDelta `json:"tag_deltas"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// 一周的第一天
+const (
+	WeekStartMonday = "monday"
+	WeekStartSunday = "sunday"
+)
+
+// UserSettings 用户偏好设置
+type UserSettings struct {
+	UserID    string `json:"user_id,omitempty"`
+	WeekStart string `json:"week_start"` // monday (默认) 或 sunday
+	Timezone  string `json:"timezone"`   // IANA 时区名，如 Asia/Shanghai
+
+	// BalanceTargets 生活平衡目标，标签 -> 期望占比 (百分比，合计 100)
+	BalanceTargets map[string]float64 `json:"balance_targets"`
+}
+
+// DefaultSettings 未保存过设置的用户使用的默认值
+func DefaultSettings(userID string) UserSettings {
+	return UserSettings{UserID: userID, WeekStart: WeekStartMonday}
+}
+
+// SundayFirst 是否以周日为一周的第一天
+func (s UserSettings) SundayFirst() bool {
+	return s.WeekStart == WeekStartSunday
+}
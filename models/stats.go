@@ -1,11 +1,33 @@
 package models
 
+// 统计周期相对今天的状态
+const (
+	PeriodPast    = "past"
+	PeriodCurrent = "current"
+	PeriodFuture  = "future"
+)
+
+// DailyAverages 日均值，分别以已过去天数、有记录的天数和日历天数为分母
+type DailyAverages struct {
+	CalendarDays          int     `json:"calendar_days"`
+	ElapsedDays           int     `json:"elapsed_days"`
+	ActiveDays            int     `json:"active_days"`
+	RecordsPerElapsedDay  float64 `json:"records_per_elapsed_day"`
+	RecordsPerActiveDay   float64 `json:"records_per_active_day"`
+	RecordsPerCalendarDay float64 `json:"records_per_calendar_day"`
+	MinutesPerElapsedDay  float64 `json:"minutes_per_elapsed_day"`
+	MinutesPerActiveDay   float64 `json:"minutes_per_active_day"`
+	MinutesPerCalendarDay float64 `json:"minutes_per_calendar_day"`
+}
+
 // YearlyStatsResponse 年度统计返回
 type YearlyStatsResponse struct {
+	PeriodStatus  string          `json:"period_status"`
 	TotalRecords  int             `json:"total_records"`
 	TotalDuration int             `json:"total_duration"`
 	TagStats      []YearlyTagStat `json:"tag_stats"`
 	MonthlyTrend  []MonthlyTrend  `json:"monthly_trend"`
+	Averages      DailyAverages   `json:"averages"`
 }
 
 // YearlyTagStat 年度标签统计
@@ -25,7 +47,9 @@ type MonthlyTrend struct {
 
 // MonthlyStatsResponse 月度统计返回
 type MonthlyStatsResponse struct {
-	DailyAverage float64          `json:"daily_average"`
+	PeriodStatus string           `json:"period_status"`
+	DailyAverage float64          `json:"daily_average"` // 已过去天数的日均记录数
+	Averages     DailyAverages    `json:"averages"`
 	TagStats     []MonthlyTagStat `json:"tag_stats"`
 }
 
@@ -35,3 +59,242 @@ type MonthlyTagStat struct {
 	Count    int    `json:"count"`
 	Duration int    `json:"duration"`
 }
+
+// HeatmapResponse 年度每日活跃热力图返回
+type HeatmapResponse struct {
+	PeriodStatus string       `json:"period_status"`
+	Year         int          `json:"year"`
+	Tag          string       `json:"tag,omitempty"`
+	MaxMinutes   int          `json:"max_minutes"`
+	Days         []HeatmapDay `json:"days"`
+}
+
+// HeatmapDay 热力图单日数据
+type HeatmapDay struct {
+	Date    string `json:"date"`
+	Minutes int    `json:"minutes"`
+	Count   int    `json:"count"`
+	Level   int    `json:"level"` // 活跃等级 0-4
+}
+
+// ComparisonResponse 两个时间段的对比结果
+type ComparisonResponse struct {
+	Current      PeriodSummary `json:"current"`
+	Previous     PeriodSummary `json:"previous"`
+	DeltaMinutes int           `json:"delta_minutes"`
+	DeltaPercent *float64      `json:"delta_percent"` // 上期为 0 时无法计算，返回 null
+	TagDeltas    []TagDelta    `json:"tag_deltas"`
+	NewTags      []string      `json:"new_tags"`
+	DroppedTags  []string      `json:"dropped_tags"`
+}
+
+// PeriodSummary 时间段汇总 (日期均为闭区间)
+type PeriodSummary struct {
+	PeriodStatus string `json:"period_status"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	TotalRecords int    `json:"total_records"`
+	TotalMinutes int    `json:"total_minutes"`
+}
+
+// TagDelta 单个标签的前后变化
+type TagDelta struct {
+	Tag             string   `json:"tag"`
+	CurrentMinutes  int      `json:"current_minutes"`
+	PreviousMinutes int      `json:"previous_minutes"`
+	CurrentCount    int      `json:"current_count"`
+	PreviousCount   int      `json:"previous_count"`
+	DeltaMinutes    int      `json:"delta_minutes"`
+	DeltaPercent    *float64 `json:"delta_percent"`
+}
+
+// PunchCardResponse 按星期 × 小时的分布统计 (打卡图)
+type PunchCardResponse struct {
+	PeriodStatus string         `json:"period_status"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	Timezone     string         `json:"timezone"`
+	Overall      PunchCard      `json:"overall"`
+	Tags         []TagPunchCard `json:"tags"`
+}
+
+// PunchCard 7×24 分布矩阵，行为星期 (0=周一 ... 6=周日)，列为小时 (0-23)
+type PunchCard struct {
+	Minutes   [7][24]int `json:"minutes"`
+	Counts    [7][24]int `json:"counts"`
+	ByWeekday [7]int     `json:"by_weekday"` // 各星期合计分钟
+	ByHour    [24]int    `json:"by_hour"`    // 各小时合计分钟
+}
+
+// TagPunchCard 单个标签的分布矩阵
+type TagPunchCard struct {
+	Tag string `json:"tag"`
+	PunchCard
+}
+
+// QueryResponse 通用统计查询返回
+type QueryResponse struct {
+	PeriodStatus string             `json:"period_status"`
+	From         string             `json:"from"`
+	To           string             `json:"to"`
+	Timezone     string             `json:"timezone"`
+	Granularity  string             `json:"granularity,omitempty"`
+	GroupBy      []string           `json:"group_by"`
+	Total        map[string]float64 `json:"total"`
+	Buckets      []QueryBucket      `json:"buckets"`
+}
+
+// QueryBucket 通用统计查询的分桶
+type QueryBucket struct {
+	Period string                 `json:"period,omitempty"` // 2026-02-21 / 2026-W08 / 2026-02 / 2026-Q1 / 2026
+	Start  string                 `json:"start,omitempty"`
+	Group  map[string]interface{} `json:"group,omitempty"`
+	Values map[string]float64     `json:"values"`
+}
+
+// RangeStatsResponse 自定义日期范围统计返回
+type RangeStatsResponse struct {
+	PeriodStatus string         `json:"period_status"`
+	From         string         `json:"from"`
+	To           string         `json:"to"`
+	TotalRecords int            `json:"total_records"`
+	TotalMinutes int            `json:"total_minutes"`
+	TotalHours   float64        `json:"total_hours"`
+	ElapsedDays  int            `json:"elapsed_days"`  // 范围内截至今天已经过去的天数
+	DailyAverage float64        `json:"daily_average"` // 已过去天数的日均分钟数
+	Averages     DailyAverages  `json:"averages"`
+	TagStats     []RangeTagStat `json:"tag_stats"`
+	Days         []RangeDayStat `json:"days"`
+	BusiestDay   *RangeDayStat  `json:"busiest_day"`  // 时长最多的一天
+	QuietestDay  *RangeDayStat  `json:"quietest_day"` // 已过去的天数中时长最少的一天
+}
+
+// RangeTagStat 自定义范围内的标签统计
+type RangeTagStat struct {
+	Tag     string  `json:"tag"`
+	Count   int     `json:"count"`
+	Minutes int     `json:"minutes"`
+	Hours   float64 `json:"hours"`
+	Ratio   float64 `json:"ratio"` // 0-1
+}
+
+// RangeDayStat 自定义范围内的单日统计
+type RangeDayStat struct {
+	Date    string `json:"date"`
+	Count   int    `json:"count"`
+	Minutes int    `json:"minutes"`
+}
+
+// TrendResponse 趋势统计返回
+type TrendResponse struct {
+	PeriodStatus string     `json:"period_status"`
+	From         string     `json:"from"`
+	To           string     `json:"to"`
+	Timezone     string     `json:"timezone"`
+	Overall      TagTrend   `json:"overall"`
+	Tags         []TagTrend `json:"tags"`
+}
+
+// TagTrend 单个标签 (或全部) 的趋势
+type TagTrend struct {
+	Tag             string       `json:"tag,omitempty"`
+	Points          []TrendPoint `json:"points"`
+	SlopePerDay     float64      `json:"slope_per_day"`  // 每日时长的线性回归斜率 (分钟/天)
+	ChangePercent   *float64     `json:"change_percent"` // 最近 30 天相比之前 30 天的变化百分比
+	MonthToDate     int          `json:"month_to_date"`
+	MonthProjection int          `json:"month_projection"` // 按当前节奏预计的本月总时长
+	YearToDate      int          `json:"year_to_date"`
+	YearProjection  int          `json:"year_projection"` // 按当前节奏预计的全年总时长
+}
+
+// TrendPoint 趋势中的单日数据 (分钟)
+type TrendPoint struct {
+	Date    string  `json:"date"`
+	Minutes int     `json:"minutes"`
+	Avg7    float64 `json:"avg_7"`
+	Avg30   float64 `json:"avg_30"`
+}
+
+// DurationStatsResponse 单次时长分布统计返回
+type DurationStatsResponse struct {
+	PeriodStatus  string             `json:"period_status"`
+	From          string             `json:"from"`
+	To            string             `json:"to"`
+	DeepThreshold int                `json:"deep_threshold"` // 深度专注的时长阈值 (分钟)
+	Overall       DurationStats      `json:"overall"`
+	Tags          []TagDurationStats `json:"tags"`
+}
+
+// DurationStats 单次时长分布 (分钟)
+type DurationStats struct {
+	Count       int              `json:"count"`
+	Mean        float64          `json:"mean"`
+	Median      float64          `json:"median"`
+	P90         float64          `json:"p90"`
+	Max         int              `json:"max"`
+	DeepCount   int              `json:"deep_count"`   // 时长不低于阈值的次数
+	DeepMinutes int              `json:"deep_minutes"` // 深度专注累计时长
+	Histogram   []DurationBucket `json:"histogram"`
+}
+
+// TagDurationStats 单个标签的时长分布
+type TagDurationStats struct {
+	Tag string `json:"tag"`
+	DurationStats
+}
+
+// DurationBucket 时长直方图分桶，区间为 [Min, Max)，Max 为 0 表示无上限
+type DurationBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// TransitionsResponse 标签转移与同日共现分析返回，矩阵的行列顺序与 Tags 一致
+type TransitionsResponse struct {
+	PeriodStatus  string          `json:"period_status"`
+	From          string          `json:"from"`
+	To            string          `json:"to"`
+	Timezone      string          `json:"timezone"`
+	Tags          []string        `json:"tags"`
+	Transitions   [][]int         `json:"transitions"`   // [i][j]: 标签 i 之后紧接着标签 j 的次数
+	Probabilities [][]float64     `json:"probabilities"` // 按行归一化的转移概率
+	CoOccurrence  [][]int         `json:"co_occurrence"` // [i][j]: 标签 i 与 j 出现在同一天的天数，对角线为标签出现的天数
+	Top           []TagTransition `json:"top"`           // 次数最多的转移
+}
+
+// TagTransition 一种标签转移
+type TagTransition struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Count       int     `json:"count"`
+	Probability float64 `json:"probability"`
+}
+
+// BalanceResponse 生活平衡评分返回
+type BalanceResponse struct {
+	PeriodStatus string             `json:"period_status"`
+	From         string             `json:"from"`
+	To           string             `json:"to"`
+	Period       string             `json:"period"` // week 或 month
+	Targets      map[string]float64 `json:"targets"`
+	Periods      []BalanceScore     `json:"periods"`
+}
+
+// BalanceScore 单个周期的平衡评分
+// 评分 = 100 × (1 - ½ Σ|实际占比 - 目标占比|)，完全符合目标为 100；没有记录时为 null
+type BalanceScore struct {
+	Period       string         `json:"period"` // 2026-W08 / 2026-02 / 2026
+	Start        string         `json:"start"`
+	TotalMinutes int            `json:"total_minutes"`
+	Score        *float64       `json:"score"`
+	Tags         []TagDeviation `json:"tags"`
+}
+
+// TagDeviation 单个标签实际占比与目标的偏差 (百分比)
+type TagDeviation struct {
+	Tag           string  `json:"tag"`
+	TargetPercent float64 `json:"target_percent"`
+	ActualPercent float64 `json:"actual_percent"`
+	Deviation     float64 `json:"deviation"` // 实际 - 目标
+}
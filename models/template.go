@@ -0,0 +1,63 @@
+package models
+
+// 导出模板适用的报告类型
+const (
+	TemplateKindWeek = "week"
+	TemplateKindYear = "year"
+)
+
+// ExportTemplate 用户自定义的导出模板 (Go text/template 语法)
+type ExportTemplate struct {
+	ID        string `json:"id,omitempty"`
+	UserID    string `json:"user_id,omitempty"`
+	Name      string `json:"name" binding:"required,max=50"`
+	Kind      string `json:"kind" binding:"required,oneof=week year"`
+	Body      string `json:"body" binding:"required"`
+	CreatedAt string `json:"created_at,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// TemplateData 导出模板可以使用的数据
+//
+// 模板中以字段名访问，例如:
+//
+//	{{.Title}} ({{.From}} ~ {{.To}})
+//	{{range .Tags}}- {{.Tag}}: {{hours .Minutes}} 小时 ({{.Percent}}%)
+//	{{end}}共 {{.TotalCount}} 条记录，最长连续 {{.Streak.Days}} 天
+type TemplateData struct {
+	Kind  string `json:"kind"`  // week 或 year
+	Title string `json:"title"` // 默认标题，如 "周总结"、"2026 年度精进报告"
+	From  string `json:"from"`  // 报告起始日期 (含)
+	To    string `json:"to"`    // 报告结束日期 (含)
+	Year  int    `json:"year"`  // 报告所在年份
+
+	Records      []TemplateRecord `json:"records"` // 按时间先后排列的记录
+	Tags         []TemplateTag    `json:"tags"`    // 按时长从多到少排列的标签汇总
+	TotalCount   int              `json:"total_count"`
+	TotalMinutes int              `json:"total_minutes"`
+	TotalHours   float64          `json:"total_hours"`
+	ActiveDays   int              `json:"active_days"` // 有记录的天数
+	Streak       Streak           `json:"streak"`      // 范围内最长连续记录
+
+	// Goals 生活平衡目标与实际占比，未设置目标时为空
+	Goals        []TagDeviation `json:"goals"`
+	BalanceScore *float64       `json:"balance_score"`
+}
+
+// TemplateRecord 模板中的一条记录
+type TemplateRecord struct {
+	Date    string `json:"date"` // 2026-02-16
+	Time    string `json:"time"` // 09:30
+	Tag     string `json:"tag"`
+	Content string `json:"content"`
+	Minutes int    `json:"minutes"`
+}
+
+// TemplateTag 模板中的标签汇总
+type TemplateTag struct {
+	Tag     string  `json:"tag"`
+	Count   int     `json:"count"`
+	Minutes int     `json:"minutes"`
+	Hours   float64 `json:"hours"`
+	Percent float64 `json:"percent"` // 占总时长的百分比
+}
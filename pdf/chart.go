@@ -0,0 +1,86 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+)
+
+// Palette 图表默认配色
+var Palette = []Color{
+	Hex("#4E79A7"), Hex("#F28E2B"), Hex("#E15759"), Hex("#76B7B2"), Hex("#59A14F"),
+	Hex("#EDC948"), Hex("#B07AA1"), Hex("#FF9DA7"), Hex("#9C755F"), Hex("#BAB0AC"),
+}
+
+var (
+	axisColor  = Hex("#999999")
+	labelColor = Hex("#555555")
+)
+
+// BarChart 在 (x, y) 为左上角、w×h 的区域内绘制柱状图，labels 与 values 一一对应
+func (p *Page) BarChart(x, y, w, h float64, labels []string, values []float64, color Color) {
+	const labelSize, labelHeight = 7.0, 12.0
+	if len(values) == 0 {
+		return
+	}
+	maxValue := 0.0
+	for _, v := range values {
+		maxValue = math.Max(maxValue, v)
+	}
+
+	plotTop, plotBottom := y+labelHeight, y+h-labelHeight
+	p.Line(x, plotBottom, x+w, plotBottom, 0.5, axisColor)
+
+	slot := w / float64(len(values))
+	barWidth := slot * 0.6
+	for i, v := range values {
+		cx := x + slot*float64(i) + slot/2
+		if maxValue > 0 && v > 0 {
+			barHeight := (plotBottom - plotTop) * v / maxValue
+			p.Rect(cx-barWidth/2, plotBottom-barHeight, barWidth, barHeight, color)
+			value := formatValue(v)
+			p.Text(cx-p.doc.TextWidth(value, labelSize)/2, plotBottom-barHeight-2, labelSize, labelColor, value)
+		}
+		if i < len(labels) {
+			p.Text(cx-p.doc.TextWidth(labels[i], labelSize)/2, y+h-2, labelSize, labelColor, labels[i])
+		}
+	}
+}
+
+// PieChart 以 (cx, cy) 为圆心绘制饼图，并在右侧 legendX 处绘制图例
+func (p *Page) PieChart(cx, cy, r float64, labels []string, values []float64, legendX float64) {
+	const legendSize = 8.0
+	total := 0.0
+	for _, v := range values {
+		total += math.Max(v, 0)
+	}
+	if total <= 0 {
+		return
+	}
+
+	angle := 0.0
+	for i, v := range values {
+		if v <= 0 {
+			continue
+		}
+		color := Palette[i%len(Palette)]
+		sweep := 2 * math.Pi * v / total
+		p.Wedge(cx, cy, r, angle, angle+sweep, color)
+		angle += sweep
+
+		ly := cy - r + float64(i)*14
+		p.Rect(legendX, ly, 8, 8, color)
+		label := ""
+		if i < len(labels) {
+			label = labels[i]
+		}
+		p.Text(legendX+12, ly+7, legendSize, labelColor, fmt.Sprintf("%s %.1f%%", label, v*100/total))
+	}
+}
+
+// formatValue 柱顶数值标签，整数不显示小数
+func formatValue(v float64) string {
+	if v == math.Trunc(v) {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.1f", v)
+}
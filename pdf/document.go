@@ -0,0 +1,324 @@
+// Package pdf 以纯 Go 生成简单的 PDF 报告
+//
+// 支持文本、矩形、线条与扇形，足以绘制表格与简单图表。传入 TrueType 字体时会以
+// CIDFontType2 子集嵌入，从而正确显示中文；未提供字体时退回到内置的 Helvetica，
+// 只能显示拉丁字符。
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// A4 纸张尺寸 (单位: 点)
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Color RGB 颜色
+type Color struct {
+	R, G, B uint8
+}
+
+// Hex 解析 #RRGGBB 形式的颜色，格式不正确时返回黑色
+func Hex(s string) Color {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || len(strings.TrimPrefix(s, "#")) != 6 {
+		return Color{}
+	}
+	return Color{uint8(v >> 16), uint8(v >> 8), uint8(v)}
+}
+
+func (c Color) String() string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// Document PDF 文档
+type Document struct {
+	font  *Font
+	used  map[uint16]bool
+	runes map[uint16]rune
+	pages []*Page
+	title string
+}
+
+// New 创建文档，font 为 nil 时使用 Helvetica
+func New(font *Font) *Document {
+	return &Document{font: font, used: make(map[uint16]bool), runes: make(map[uint16]rune)}
+}
+
+// SetTitle 设置文档标题 (显示在阅读器的标题栏)
+func (d *Document) SetTitle(title string) {
+	d.title = title
+}
+
+// AddPage 添加一页 A4 纸
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// TextWidth 计算文本在给定字号下的宽度
+func (d *Document) TextWidth(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		if d.font != nil {
+			total += d.font.advance(d.font.glyph(r))
+		} else {
+			total += helveticaWidth(r)
+		}
+	}
+	return float64(total) * size / 1000
+}
+
+// Page 页面，坐标以左上角为原点、向下为正
+type Page struct {
+	doc     *Document
+	content bytes.Buffer
+}
+
+// Text 在 (x, y) 处绘制文本，y 为基线位置
+func (p *Page) Text(x, y, size float64, color Color, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT %s rg /F1 %.2f Tf %.2f %.2f Td %s Tj ET\n",
+		color, size, x, PageHeight-y, p.doc.encode(s))
+}
+
+// Rect 填充矩形，(x, y) 为左上角
+func (p *Page) Rect(x, y, w, h float64, color Color) {
+	fmt.Fprintf(&p.content, "%s rg %.2f %.2f %.2f %.2f re f\n", color, x, PageHeight-y-h, w, h)
+}
+
+// Line 绘制线段
+func (p *Page) Line(x1, y1, x2, y2, width float64, color Color) {
+	fmt.Fprintf(&p.content, "%s RG %.2f w %.2f %.2f m %.2f %.2f l S\n",
+		color, width, x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// Wedge 填充以 (cx, cy) 为圆心的扇形，角度单位为弧度，从 12 点方向顺时针计算
+func (p *Page) Wedge(cx, cy, r, start, end float64, color Color) {
+	if end <= start {
+		return
+	}
+	point := func(a float64) (float64, float64) {
+		return cx + r*math.Sin(a), PageHeight - (cy - r*math.Cos(a))
+	}
+	fmt.Fprintf(&p.content, "%s rg %.2f %.2f m", color, cx, PageHeight-cy)
+	steps := int(math.Ceil((end - start) / (math.Pi / 90)))
+	for i := 0; i <= steps; i++ {
+		x, y := point(start + (end-start)*float64(i)/float64(steps))
+		fmt.Fprintf(&p.content, " %.2f %.2f l", x, y)
+	}
+	p.content.WriteString(" h f\n")
+}
+
+// encode 将文本编码为 PDF 字符串，并记录用到的字形
+func (d *Document) encode(s string) string {
+	var b strings.Builder
+	if d.font == nil {
+		b.WriteByte('(')
+		for _, r := range s {
+			if r > 0xFF {
+				r = '?'
+			}
+			switch r {
+			case '(', ')', '\\':
+				b.WriteByte('\\')
+			}
+			b.WriteByte(byte(r))
+		}
+		b.WriteByte(')')
+		return b.String()
+	}
+
+	b.WriteByte('<')
+	for _, r := range s {
+		gid := d.font.glyph(r)
+		d.used[gid] = true
+		if _, ok := d.runes[gid]; !ok {
+			d.runes[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// Write 将文档写入 w
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	pw := &writer{}
+	pw.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// 对象编号: 1 目录, 2 页面树, 3 信息, 4 字体, 之后为字体附属对象和各页
+	catalog, pagesID, infoID, fontID := 1, 2, 3, 4
+	pw.next = 5
+
+	pw.object(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	info := "<< /Producer (daily-records) "
+	if d.title != "" {
+		info += "/Title " + utf16Text(d.title) + " "
+	}
+	pw.object(infoID, info+">>")
+	d.writeFont(pw, fontID)
+
+	kids := make([]string, len(d.pages))
+	for i, p := range d.pages {
+		pageID, contentID := pw.alloc(), pw.alloc()
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+		pw.object(pageID, fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesID, PageWidth, PageHeight, fontID, contentID))
+		pw.stream(contentID, "", p.content.Bytes())
+	}
+	pw.object(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	pw.finish(catalog, infoID)
+	_, err := w.Write(pw.buf.Bytes())
+	return err
+}
+
+// writeFont 写出字体对象；嵌入字体时只包含文档中实际用到的字形
+func (d *Document) writeFont(pw *writer, fontID int) {
+	f := d.font
+	if f == nil {
+		pw.object(fontID, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+		return
+	}
+
+	cidID, descID, fileID, toUnicodeID := pw.alloc(), pw.alloc(), pw.alloc(), pw.alloc()
+	const name = "/DRPTAA+Embedded"
+
+	pw.object(fontID, fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont %s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, cidID, toUnicodeID))
+
+	gids := make([]int, 0, len(d.used))
+	for gid := range d.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, f.advance(uint16(gid)))
+	}
+	pw.object(cidID, fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont %s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /DW 1000 /W [%s] /CIDToGIDMap /Identity >>",
+		name, descID, widths.String()))
+
+	pw.object(descID, fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName %s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle 0 "+
+			"/Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.ascent), fileID))
+
+	data := f.subset(d.used)
+	pw.stream(fileID, fmt.Sprintf("/Length1 %d ", len(data)), data)
+	pw.stream(toUnicodeID, "", d.toUnicode(gids))
+}
+
+// toUnicode 生成字形到 Unicode 的映射，使 PDF 中的文字可以复制和搜索
+func (d *Document) toUnicode(gids []int) []byte {
+	var b bytes.Buffer
+	b.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(gids); i += 100 {
+		chunk := gids[i:min(i+100, len(gids))]
+		fmt.Fprintf(&b, "%d beginbfchar\n", len(chunk))
+		for _, gid := range chunk {
+			var hex strings.Builder
+			for _, u := range utf16.Encode([]rune{d.runes[uint16(gid)]}) {
+				fmt.Fprintf(&hex, "%04X", u)
+			}
+			fmt.Fprintf(&b, "<%04X> <%s>\n", gid, hex.String())
+		}
+		b.WriteString("endbfchar\n")
+	}
+	b.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	return b.Bytes()
+}
+
+// writer 记录对象偏移量，用于生成交叉引用表
+type writer struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+	next    int
+}
+
+func (w *writer) alloc() int {
+	id := w.next
+	w.next++
+	return id
+}
+
+func (w *writer) object(id int, body string) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+}
+
+// stream 写出 Flate 压缩的流对象，extra 为附加的字典项
+func (w *writer) stream(id int, extra string, data []byte) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(data)
+	zw.Close()
+
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< %s/Length %d /Filter /FlateDecode >>\nstream\n", id, extra, z.Len())
+	w.buf.Write(z.Bytes())
+	w.buf.WriteString("\nendstream\nendobj\n")
+}
+
+func (w *writer) finish(root, info int) {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", w.next)
+	for id := 1; id < w.next; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", w.next, root, info, xref)
+}
+
+// utf16Text 将文本编码为带 BOM 的 UTF-16BE 十六进制字符串
+func utf16Text(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// helveticaWidth Helvetica 字宽的近似值 (1000 单位制)
+func helveticaWidth(r rune) int {
+	switch {
+	case r == ' ' || r == 'i' || r == 'l' || r == 'j' || r == '.' || r == ',' || r == ':' || r == '|':
+		return 278
+	case r >= 'A' && r <= 'Z', r == 'm' || r == 'w' || r == '%':
+		return 722
+	default:
+		return 556
+	}
+}
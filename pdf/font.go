@@ -0,0 +1,380 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Font 已解析的 TrueType 字体 (支持 .ttf 以及 .ttc 中的第一个字体)
+//
+// 只支持 glyf 轮廓的字体；CFF 轮廓的 OpenType 字体 (如 Noto Sans CJK 的 .otf) 无法嵌入。
+type Font struct {
+	tables     map[string][]byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	bbox       [4]int
+	numGlyphs  int
+	longLoca   bool
+	advances   []int
+	cmap       map[rune]uint16
+}
+
+// 嵌入 PDF 时保留的表 (PDF 规范要求的 TrueType 子集)
+var embedTables = []string{"cvt ", "fpgm", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "prep"}
+
+// LoadFont 读取并解析字体文件
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFont(data)
+}
+
+// ParseFont 解析 TrueType 字体数据
+func ParseFont(data []byte) (*Font, error) {
+	offset := 0
+	if len(data) >= 16 && string(data[:4]) == "ttcf" {
+		// 字体集合取第一个字体
+		offset = int(binary.BigEndian.Uint32(data[12:]))
+	}
+	if len(data) < offset+12 {
+		return nil, errors.New("字体文件过短")
+	}
+	switch string(data[offset : offset+4]) {
+	case "\x00\x01\x00\x00", "true":
+	case "OTTO":
+		return nil, errors.New("不支持 CFF 轮廓的 OpenType 字体，请使用 TrueType 字体")
+	default:
+		return nil, errors.New("无法识别的字体格式")
+	}
+
+	numTables := int(binary.BigEndian.Uint16(data[offset+4:]))
+	f := &Font{tables: make(map[string][]byte)}
+	for i := 0; i < numTables; i++ {
+		rec := offset + 12 + i*16
+		if len(data) < rec+16 {
+			return nil, errors.New("字体表目录不完整")
+		}
+		tag := string(data[rec : rec+4])
+		start := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if start < 0 || length < 0 || start+length > len(data) {
+			return nil, fmt.Errorf("字体表 %q 越界", tag)
+		}
+		f.tables[tag] = data[start : start+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "loca", "glyf", "cmap"} {
+		if f.tables[tag] == nil {
+			return nil, fmt.Errorf("字体缺少 %q 表", tag)
+		}
+	}
+
+	head, hhea, maxp := f.tables["head"], f.tables["hhea"], f.tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errors.New("字体表数据不完整")
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("字体 unitsPerEm 为 0")
+	}
+	for i := range f.bbox {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2:])))
+	}
+	f.longLoca = binary.BigEndian.Uint16(head[50:]) == 1
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	// 水平步进宽度，超出 numberOfHMetrics 的字形沿用最后一个宽度
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := f.tables["hmtx"]
+	if numMetrics == 0 || len(hmtx) < numMetrics*4 {
+		return nil, errors.New("字体 hmtx 表不完整")
+	}
+	f.advances = make([]int, f.numGlyphs)
+	for gid := range f.advances {
+		m := gid
+		if m >= numMetrics {
+			m = numMetrics - 1
+		}
+		f.advances[gid] = int(binary.BigEndian.Uint16(hmtx[m*4:]))
+	}
+
+	cmap, err := parseCmap(f.tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.cmap = cmap
+	return f, nil
+}
+
+// glyph 返回字符对应的字形编号，字体中没有的字符返回 0 (.notdef)
+func (f *Font) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// advance 返回字形宽度 (以 1000 为一个字号单位)
+func (f *Font) advance(gid uint16) int {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return f.advances[gid] * 1000 / f.unitsPerEm
+}
+
+// scale 将字体设计单位换算为 1000 单位制
+func (f *Font) scale(v int) int {
+	return v * 1000 / f.unitsPerEm
+}
+
+// parseCmap 解析 Unicode 字符映射 (优先 format 12，其次 format 4)
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errors.New("字体 cmap 表不完整")
+	}
+	var fmt4, fmt12 []byte
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < n; i++ {
+		rec := 4 + i*8
+		if len(cmap) < rec+8 {
+			break
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+2 > len(cmap) {
+			continue
+		}
+		sub := cmap[off:]
+		switch binary.BigEndian.Uint16(sub) {
+		case 4:
+			if platform == 3 && encoding == 1 || platform == 0 {
+				fmt4 = sub
+			}
+		case 12:
+			if platform == 3 && encoding == 10 || platform == 0 {
+				fmt12 = sub
+			}
+		}
+	}
+
+	m := make(map[rune]uint16)
+	switch {
+	case fmt12 != nil && len(fmt12) >= 16:
+		groups := int(binary.BigEndian.Uint32(fmt12[12:]))
+		for i := 0; i < groups && 16+i*12+12 <= len(fmt12); i++ {
+			g := fmt12[16+i*12:]
+			start := binary.BigEndian.Uint32(g)
+			end := binary.BigEndian.Uint32(g[4:])
+			gid := binary.BigEndian.Uint32(g[8:])
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				m[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case fmt4 != nil && len(fmt4) >= 14:
+		segs := int(binary.BigEndian.Uint16(fmt4[6:])) / 2
+		if len(fmt4) < 16+segs*8 {
+			return nil, errors.New("字体 cmap 表不完整")
+		}
+		ends := fmt4[14:]
+		starts := fmt4[16+segs*2:]
+		deltas := fmt4[16+segs*4:]
+		rangeOffsets := fmt4[16+segs*6:]
+		for i := 0; i < segs; i++ {
+			start := int(binary.BigEndian.Uint16(starts[i*2:]))
+			end := int(binary.BigEndian.Uint16(ends[i*2:]))
+			delta := int(binary.BigEndian.Uint16(deltas[i*2:]))
+			ro := int(binary.BigEndian.Uint16(rangeOffsets[i*2:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				var gid int
+				if ro == 0 {
+					gid = (c + delta) & 0xFFFF
+				} else {
+					idx := 16 + segs*6 + i*2 + ro + (c-start)*2
+					if idx+2 > len(fmt4) {
+						continue
+					}
+					gid = int(binary.BigEndian.Uint16(fmt4[idx:]))
+					if gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				if gid != 0 {
+					m[rune(c)] = uint16(gid)
+				}
+			}
+		}
+	default:
+		return nil, errors.New("字体缺少 Unicode 字符映射")
+	}
+	return m, nil
+}
+
+// subset 生成只保留 used 字形轮廓的字体文件
+//
+// 字形编号保持不变 (未使用的字形轮廓置空)，因此 PDF 中可以直接用字形编号作为 CID。
+func (f *Font) subset(used map[uint16]bool) []byte {
+	glyf := f.tables["glyf"]
+	loca := f.tables["loca"]
+	offsetOf := func(gid int) int {
+		if f.longLoca {
+			if len(loca) < gid*4+4 {
+				return len(glyf)
+			}
+			return int(binary.BigEndian.Uint32(loca[gid*4:]))
+		}
+		if len(loca) < gid*2+2 {
+			return len(glyf)
+		}
+		return int(binary.BigEndian.Uint16(loca[gid*2:])) * 2
+	}
+	glyphData := func(gid int) []byte {
+		start, end := offsetOf(gid), offsetOf(gid+1)
+		if start >= end || end > len(glyf) {
+			return nil
+		}
+		return glyf[start:end]
+	}
+
+	// 复合字形依赖的组件字形也需要保留
+	keep := make(map[uint16]bool, len(used)+1)
+	queue := []uint16{0}
+	for gid := range used {
+		queue = append(queue, gid)
+	}
+	for len(queue) > 0 {
+		gid := queue[0]
+		queue = queue[1:]
+		if keep[gid] || int(gid) >= f.numGlyphs {
+			continue
+		}
+		keep[gid] = true
+		for _, comp := range componentGlyphs(glyphData(int(gid))) {
+			queue = append(queue, comp)
+		}
+	}
+
+	var newGlyf bytes.Buffer
+	newLoca := make([]byte, (f.numGlyphs+1)*4)
+	for gid := 0; gid < f.numGlyphs; gid++ {
+		binary.BigEndian.PutUint32(newLoca[gid*4:], uint32(newGlyf.Len()))
+		if keep[uint16(gid)] {
+			newGlyf.Write(glyphData(gid))
+			for newGlyf.Len()%4 != 0 {
+				newGlyf.WriteByte(0)
+			}
+		}
+	}
+	binary.BigEndian.PutUint32(newLoca[f.numGlyphs*4:], uint32(newGlyf.Len()))
+
+	head := append([]byte(nil), f.tables["head"]...)
+	binary.BigEndian.PutUint16(head[50:], 1) // indexToLocFormat: long
+	binary.BigEndian.PutUint32(head[8:], 0)  // checkSumAdjustment
+
+	tables := map[string][]byte{}
+	for _, tag := range embedTables {
+		if data := f.tables[tag]; data != nil {
+			tables[tag] = data
+		}
+	}
+	tables["glyf"] = newGlyf.Bytes()
+	tables["loca"] = newLoca
+	tables["head"] = head
+	return writeSfnt(tables)
+}
+
+// componentGlyphs 返回复合字形引用的组件字形
+func componentGlyphs(g []byte) []uint16 {
+	if len(g) < 10 || int16(binary.BigEndian.Uint16(g)) >= 0 {
+		return nil
+	}
+	var comps []uint16
+	const (
+		argsAreWords   = 0x0001
+		haveScale      = 0x0008
+		moreComponents = 0x0020
+		haveXYScale    = 0x0040
+		haveTwoByTwo   = 0x0080
+	)
+	p := 10
+	for p+4 <= len(g) {
+		flags := binary.BigEndian.Uint16(g[p:])
+		comps = append(comps, binary.BigEndian.Uint16(g[p+2:]))
+		p += 4
+		if flags&argsAreWords != 0 {
+			p += 4
+		} else {
+			p += 2
+		}
+		switch {
+		case flags&haveScale != 0:
+			p += 2
+		case flags&haveXYScale != 0:
+			p += 4
+		case flags&haveTwoByTwo != 0:
+			p += 8
+		}
+		if flags&moreComponents == 0 {
+			break
+		}
+	}
+	return comps
+}
+
+// writeSfnt 将字体表写成独立的 TrueType 文件
+func writeSfnt(tables map[string][]byte) []byte {
+	tags := make([]string, 0, len(tables))
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	n := len(tags)
+	entrySelector := 0
+	for 1<<(entrySelector+1) <= n {
+		entrySelector++
+	}
+	searchRange := (1 << entrySelector) * 16
+
+	var out bytes.Buffer
+	header := make([]byte, 12)
+	binary.BigEndian.PutUint32(header, 0x00010000)
+	binary.BigEndian.PutUint16(header[4:], uint16(n))
+	binary.BigEndian.PutUint16(header[6:], uint16(searchRange))
+	binary.BigEndian.PutUint16(header[8:], uint16(entrySelector))
+	binary.BigEndian.PutUint16(header[10:], uint16(n*16-searchRange))
+	out.Write(header)
+
+	offset := 12 + n*16
+	dir := make([]byte, n*16)
+	for i, tag := range tags {
+		data := tables[tag]
+		copy(dir[i*16:], tag)
+		binary.BigEndian.PutUint32(dir[i*16+4:], checksum(data))
+		binary.BigEndian.PutUint32(dir[i*16+8:], uint32(offset))
+		binary.BigEndian.PutUint32(dir[i*16+12:], uint32(len(data)))
+		offset += (len(data) + 3) &^ 3
+	}
+	out.Write(dir)
+	for _, tag := range tags {
+		data := tables[tag]
+		out.Write(data)
+		out.Write(make([]byte, (4-len(data)%4)%4))
+	}
+	return out.Bytes()
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+	return sum
+}
@@ -0,0 +1,398 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func goRegular(t *testing.T) *Font {
+	t.Helper()
+	f, err := ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatalf("ParseFont(goregular) = %v", err)
+	}
+	return f
+}
+
+func TestParseFont(t *testing.T) {
+	f := goRegular(t)
+	if f.unitsPerEm != 2048 {
+		t.Errorf("unitsPerEm = %d, want 2048", f.unitsPerEm)
+	}
+	if f.numGlyphs == 0 || len(f.advances) != f.numGlyphs {
+		t.Errorf("numGlyphs = %d, advances = %d", f.numGlyphs, len(f.advances))
+	}
+	if f.ascent <= 0 || f.descent >= 0 {
+		t.Errorf("ascent/descent = %d/%d", f.ascent, f.descent)
+	}
+
+	tests := []struct {
+		r      rune
+		mapped bool
+	}{
+		{'A', true},
+		{'z', true},
+		{'0', true},
+		{'é', true},
+		{'中', false},
+		{0x1F600, false},
+	}
+	for _, tt := range tests {
+		if gid := f.glyph(tt.r); (gid != 0) != tt.mapped {
+			t.Errorf("glyph(%q) = %d, mapped want %v", tt.r, gid, tt.mapped)
+		}
+	}
+
+	// 字宽换算为 1000 单位制: Go 字体的数字等宽
+	if w0, w1 := f.advance(f.glyph('0')), f.advance(f.glyph('1')); w0 == 0 || w0 != w1 {
+		t.Errorf("advance('0') = %d, advance('1') = %d", w0, w1)
+	}
+	if w := f.advance(uint16(f.numGlyphs)); w != 0 {
+		t.Errorf("越界字形的宽度 = %d, want 0", w)
+	}
+}
+
+func TestParseFontInvalid(t *testing.T) {
+	otto := append([]byte("OTTO"), make([]byte, 12)...)
+	ttc := append([]byte("ttcf\x00\x01\x00\x00\x00\x00\x00\x01\xff\xff\xff\xff"), make([]byte, 8)...)
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"空数据", nil, "过短"},
+		{"非字体", []byte("<html>not a font</html>"), "无法识别"},
+		{"CFF", otto, "CFF"},
+		{"字体集合偏移越界", ttc, "过短"},
+		{"目录截断", goregular.TTF[:20], "目录不完整"},
+		{"表数据截断", goregular.TTF[:len(goregular.TTF)/2], "越界"},
+	}
+	for _, tt := range tests {
+		_, err := ParseFont(tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// 任意截断或篡改字体数据都只能返回错误，不能 panic
+func TestParseFontCorrupt(t *testing.T) {
+	parse := func(data []byte) (f *Font, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+				t.Errorf("ParseFont panic: %v", r)
+			}
+		}()
+		return ParseFont(data)
+	}
+
+	for n := 0; n < len(goregular.TTF); n += 997 {
+		parse(goregular.TTF[:n])
+	}
+
+	// 篡改表目录中的偏移与长度，以及各表开头的字段
+	numTables := int(binary.BigEndian.Uint16(goregular.TTF[4:]))
+	for i := 0; i < numTables; i++ {
+		rec := 12 + i*16
+		start := int(binary.BigEndian.Uint32(goregular.TTF[rec+8:]))
+		for _, pos := range []int{rec + 8, rec + 12, start, start + 2, start + 4, start + 6, start + 12, start + 34, start + 50} {
+			for _, b := range []byte{0x00, 0x7f, 0xff} {
+				data := append([]byte(nil), goregular.TTF...)
+				if pos >= len(data) {
+					continue
+				}
+				data[pos] = b
+				if f, err := parse(data); err == nil {
+					doc := New(f)
+					doc.AddPage().Text(10, 10, 12, Color{}, "Abc é")
+					func() {
+						defer func() {
+							if r := recover(); r != nil {
+								t.Errorf("篡改字体 (表 %d 偏移 %d = %#x) 生成文档时 panic: %v", i, pos, b, r)
+							}
+						}()
+						doc.Write(io.Discard)
+					}()
+				}
+			}
+		}
+	}
+}
+
+// sfntTables 读取 TrueType 文件的表目录
+func sfntTables(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	if len(data) < 12 || binary.BigEndian.Uint32(data) != 0x00010000 {
+		t.Fatalf("子集字体头部不正确")
+	}
+	n := int(binary.BigEndian.Uint16(data[4:]))
+	tables := make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		rec := 12 + i*16
+		start := int(binary.BigEndian.Uint32(data[rec+8:]))
+		length := int(binary.BigEndian.Uint32(data[rec+12:]))
+		if start%4 != 0 || start+length > len(data) {
+			t.Fatalf("表 %q 偏移 %d 长度 %d 不正确", data[rec:rec+4], start, length)
+		}
+		if sum := binary.BigEndian.Uint32(data[rec+4:]); sum != checksum(data[start:start+length]) {
+			t.Errorf("表 %q 校验和不一致", data[rec:rec+4])
+		}
+		tables[string(data[rec:rec+4])] = data[start : start+length]
+	}
+	return tables
+}
+
+func TestSubset(t *testing.T) {
+	f := goRegular(t)
+	text := "Hello, é!"
+	used := make(map[uint16]bool)
+	for _, r := range text {
+		used[f.glyph(r)] = true
+	}
+
+	tables := sfntTables(t, f.subset(used))
+	for _, tag := range []string{"glyf", "head", "hhea", "hmtx", "loca", "maxp"} {
+		if tables[tag] == nil {
+			t.Errorf("子集缺少 %q 表", tag)
+		}
+	}
+	// 以字形编号作为 CID (CIDToGIDMap /Identity)，不需要 cmap
+	if tables["cmap"] != nil {
+		t.Error("子集不应包含 cmap 表")
+	}
+	if got := binary.BigEndian.Uint16(tables["head"][50:]); got != 1 {
+		t.Errorf("indexToLocFormat = %d, want 1", got)
+	}
+
+	loca, glyf := tables["loca"], tables["glyf"]
+	if len(loca) != (f.numGlyphs+1)*4 {
+		t.Fatalf("loca 长度 = %d, want %d", len(loca), (f.numGlyphs+1)*4)
+	}
+	if end := int(binary.BigEndian.Uint32(loca[f.numGlyphs*4:])); end != len(glyf) {
+		t.Errorf("loca 末项 = %d, glyf 长度 = %d", end, len(glyf))
+	}
+	if len(glyf) == 0 || len(glyf) >= len(f.tables["glyf"])/10 {
+		t.Errorf("子集 glyf 长度 = %d, 原字体 %d", len(glyf), len(f.tables["glyf"]))
+	}
+
+	outline := func(gid uint16) []byte {
+		start := binary.BigEndian.Uint32(loca[int(gid)*4:])
+		end := binary.BigEndian.Uint32(loca[int(gid)*4+4:])
+		return bytes.TrimRight(glyf[start:end], "\x00")
+	}
+	original := func(gid uint16) []byte {
+		ol := f.tables["loca"]
+		var start, end int
+		if f.longLoca {
+			start, end = int(binary.BigEndian.Uint32(ol[int(gid)*4:])), int(binary.BigEndian.Uint32(ol[int(gid)*4+4:]))
+		} else {
+			start, end = int(binary.BigEndian.Uint16(ol[int(gid)*2:]))*2, int(binary.BigEndian.Uint16(ol[int(gid)*2+2:]))*2
+		}
+		return bytes.TrimRight(f.tables["glyf"][start:end], "\x00")
+	}
+
+	// 用到的字符保留原轮廓，未用到的字符轮廓为空
+	for _, r := range "Hlo,é" {
+		gid := f.glyph(r)
+		if got := outline(gid); len(got) == 0 || !bytes.Equal(got, original(gid)) {
+			t.Errorf("字符 %q (字形 %d) 的轮廓与原字体不一致", r, gid)
+		}
+	}
+	for _, r := range "XYZ" {
+		if got := outline(f.glyph(r)); len(got) != 0 {
+			t.Errorf("未使用的字符 %q 仍保留 %d 字节轮廓", r, len(got))
+		}
+	}
+}
+
+func TestComponentGlyphs(t *testing.T) {
+	simple := make([]byte, 12)
+	binary.BigEndian.PutUint16(simple, 1) // numberOfContours > 0
+
+	// 两个组件: 第一个 16 位参数 + 统一缩放，第二个 8 位参数
+	compound := make([]byte, 10)
+	binary.BigEndian.PutUint16(compound, 0xFFFF)
+	compound = binary.BigEndian.AppendUint16(compound, 0x0001|0x0008|0x0020)
+	compound = binary.BigEndian.AppendUint16(compound, 7)
+	compound = append(compound, 0, 0, 0, 0, 0x40, 0)
+	compound = binary.BigEndian.AppendUint16(compound, 0)
+	compound = binary.BigEndian.AppendUint16(compound, 9)
+	compound = append(compound, 0, 0)
+
+	tests := []struct {
+		name string
+		g    []byte
+		want []uint16
+	}{
+		{"空", nil, nil},
+		{"简单字形", simple, nil},
+		{"复合字形", compound, []uint16{7, 9}},
+		{"截断的复合字形", compound[:16], []uint16{7}},
+	}
+	for _, tt := range tests {
+		if got := componentGlyphs(tt.g); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: componentGlyphs = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+var objPattern = regexp.MustCompile(`^(\d+) 0 obj\n`)
+
+// checkStructure 校验文件头、交叉引用表与文件尾，返回各对象的内容
+func checkStructure(t *testing.T, data []byte) map[int][]byte {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		t.Fatalf("文件头 = %q", data[:min(8, len(data))])
+	}
+	if !bytes.HasSuffix(data, []byte("%%EOF\n")) {
+		t.Fatalf("文件未以 %%%%EOF 结尾")
+	}
+
+	tail := data[bytes.LastIndex(data, []byte("startxref\n"))+len("startxref\n"):]
+	xref, err := strconv.Atoi(string(tail[:bytes.IndexByte(tail, '\n')]))
+	if err != nil || xref >= len(data) || !bytes.HasPrefix(data[xref:], []byte("xref\n0 ")) {
+		t.Fatalf("startxref 不指向交叉引用表: %v", err)
+	}
+	lines := strings.Split(string(data[xref:]), "\n")
+	size, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	if !strings.Contains(string(data), fmt.Sprintf("/Size %d ", size)) {
+		t.Errorf("trailer /Size 与交叉引用表的 %d 项不一致", size)
+	}
+	if lines[2] != "0000000000 65535 f " {
+		t.Errorf("第 0 项 = %q", lines[2])
+	}
+
+	objects := make(map[int][]byte, size)
+	for id := 1; id < size; id++ {
+		entry := lines[2+id]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("交叉引用第 %d 项格式不正确: %q", id, entry)
+		}
+		off, _ := strconv.Atoi(entry[:10])
+		m := objPattern.FindSubmatch(data[off:])
+		if m == nil || string(m[1]) != strconv.Itoa(id) {
+			t.Fatalf("交叉引用第 %d 项偏移 %d 未指向该对象", id, off)
+		}
+		end := bytes.Index(data[off:], []byte("\nendobj\n"))
+		objects[id] = data[off+len(m[0]) : off+end]
+	}
+	return objects
+}
+
+// streamData 解压流对象的内容
+func streamData(t *testing.T, obj []byte) []byte {
+	t.Helper()
+	start := bytes.Index(obj, []byte("stream\n")) + len("stream\n")
+	end := bytes.LastIndex(obj, []byte("\nendstream"))
+	m := regexp.MustCompile(`/Length (\d+)`).FindSubmatch(obj)
+	if m == nil || strconv.Itoa(end-start) != string(m[1]) {
+		t.Fatalf("流的 /Length 与实际长度 %d 不一致", end-start)
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(obj[start:end]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDocumentWrite(t *testing.T) {
+	f := goRegular(t)
+	doc := New(f)
+	doc.SetTitle("周报 2026-W08")
+	p := doc.AddPage()
+	p.Text(40, 60, 18, Hex("#333333"), "Weekly (report)")
+	p.Rect(40, 80, 100, 20, Palette[0])
+	p.Line(40, 110, 200, 110, 1, axisColor)
+	p.Wedge(300, 300, 50, 0, 1, Palette[1])
+	doc.AddPage().BarChart(40, 40, 400, 200, []string{"a", "b"}, []float64{0, 0}, Palette[2])
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	objects := checkStructure(t, buf.Bytes())
+
+	if !bytes.Contains(objects[2], []byte("/Count 2")) {
+		t.Errorf("页面树 = %s", objects[2])
+	}
+	if !bytes.Contains(objects[3], []byte("/Title <FEFF5468")) {
+		t.Errorf("信息字典 = %s", objects[3])
+	}
+	if !bytes.Contains(objects[4], []byte("/Subtype /Type0")) {
+		t.Errorf("字体 = %s", objects[4])
+	}
+
+	// 对象 5-8 依次为 CIDFont、字体描述、字体文件与 ToUnicode
+	fontFile := objects[7]
+	length1 := regexp.MustCompile(`/Length1 (\d+)`).FindSubmatch(fontFile)
+	data := streamData(t, fontFile)
+	if length1 == nil || string(length1[1]) != strconv.Itoa(len(data)) {
+		t.Errorf("/Length1 与解压后的字体长度 %d 不一致", len(data))
+	}
+	sfntTables(t, data)
+
+	cmap := streamData(t, objects[8])
+	gid := f.glyph('W')
+	if !bytes.Contains(cmap, []byte(fmt.Sprintf("<%04X> <0057>", gid))) {
+		t.Errorf("ToUnicode 缺少 W 的映射:\n%s", cmap)
+	}
+	content := streamData(t, objects[10])
+	if !bytes.Contains(content, []byte(fmt.Sprintf("<%04X", gid))) {
+		t.Errorf("页面内容未以字形编号编码文本:\n%s", content)
+	}
+}
+
+func TestDocumentWithoutFont(t *testing.T) {
+	doc := New(nil)
+	doc.AddPage().Text(10, 10, 12, Color{}, `a(b)\中`)
+
+	var buf bytes.Buffer
+	if err := doc.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	objects := checkStructure(t, buf.Bytes())
+	if !bytes.Contains(objects[4], []byte("/BaseFont /Helvetica")) {
+		t.Errorf("字体 = %s", objects[4])
+	}
+	if content := streamData(t, objects[6]); !bytes.Contains(content, []byte(`(a\(b\)\\?)`)) {
+		t.Errorf("内容 = %s", content)
+	}
+
+	// 没有页面时补一页空白页
+	buf.Reset()
+	if err := New(nil).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	checkStructure(t, buf.Bytes())
+}
+
+func TestHex(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Color
+	}{
+		{"#4E79A7", Color{0x4E, 0x79, 0xA7}},
+		{"ff0000", Color{0xFF, 0, 0}},
+		{"#FFF", Color{}},
+		{"#GG0000", Color{}},
+	}
+	for _, tt := range tests {
+		if got := Hex(tt.s); got != tt.want {
+			t.Errorf("Hex(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+-- 统计聚合下推到数据库
+-- Go 端通过 PostgREST 调用 /rest/v1/rpc/stats_hourly 获取按小时预聚合的数据，
+-- 再由 aggregate 包完成按天/周/月/标签等维度的汇总，避免逐行拉取全部记录。
+
+-- 行动开始时间 (可选)，统计时优先于 created_at
+alter table public.daily_records
+    add column if not exists started_at timestamptz;
+
+create index if not exists daily_records_user_created_idx
+    on public.daily_records (user_id, created_at);
+
+-- stats_hourly 按 (用户时区下的小时, 标签) 聚合记录
+-- 筛选条件与 Go 端一致: created_at 位于 [p_from, p_to)
+-- bucket 为该小时在 p_tz 时区下的起点 (带时区时间戳)
+create or replace function public.stats_hourly(
+    p_user_id uuid,
+    p_from    timestamptz,
+    p_to      timestamptz,
+    p_tz      text default 'UTC'
+)
+returns table (
+    bucket       timestamptz,
+    tag          text,
+    record_count integer,
+    minutes      integer
+)
+language sql
+stable
+security invoker
+as $$
+    select
+        date_trunc('hour', coalesce(r.started_at, r.created_at) at time zone p_tz) at time zone p_tz as bucket,
+        r.tag::text,
+        count(*)::integer as record_count,
+        coalesce(sum(r.duration), 0)::integer as minutes
+    from public.daily_records r
+    where r.user_id = p_user_id
+      and r.created_at >= p_from
+      and r.created_at < p_to
+    group by 1, 2
+    order by 1, 2;
+$$;
+
+grant execute on function public.stats_hourly(uuid, timestamptz, timestamptz, text) to anon, authenticated, service_role;
@@ -0,0 +1,94 @@
+-- 每日汇总表 daily_rollups
+-- 按 (用户, UTC 日期, 标签) 汇总时长与条数，由 daily_records 上的触发器在同一事务中维护，
+-- 周/月/年统计直接读取该表，无需扫描原始记录。
+-- 日期取 coalesce(started_at, created_at) 的 UTC 日期，与统计接口口径一致。
+
+create table if not exists public.daily_rollups (
+    user_id      uuid    not null,
+    day          date    not null,
+    tag          text    not null,
+    minutes      integer not null default 0,
+    record_count integer not null default 0,
+    primary key (user_id, day, tag)
+);
+
+alter table public.daily_rollups enable row level security;
+
+drop policy if exists "daily_rollups_select_own" on public.daily_rollups;
+create policy "daily_rollups_select_own" on public.daily_rollups
+    for select using (auth.uid() = user_id);
+
+-- 将一条记录以 sign (+1/-1) 计入汇总表
+create or replace function public.daily_rollup_apply(r public.daily_records, sign integer)
+returns void
+language plpgsql
+as $$
+declare
+    v_day date := (coalesce(r.started_at, r.created_at) at time zone 'UTC')::date;
+begin
+    insert into public.daily_rollups as d (user_id, day, tag, minutes, record_count)
+    values (r.user_id, v_day, r.tag, sign * coalesce(r.duration, 0), sign)
+    on conflict (user_id, day, tag) do update
+        set minutes      = d.minutes + excluded.minutes,
+            record_count = d.record_count + excluded.record_count;
+
+    delete from public.daily_rollups
+    where user_id = r.user_id and day = v_day and tag = r.tag and record_count <= 0;
+end;
+$$;
+
+create or replace function public.daily_rollup_trigger()
+returns trigger
+language plpgsql
+security definer
+set search_path = public
+as $$
+begin
+    if tg_op in ('UPDATE', 'DELETE') then
+        perform public.daily_rollup_apply(old, -1);
+    end if;
+    if tg_op in ('INSERT', 'UPDATE') then
+        perform public.daily_rollup_apply(new, 1);
+    end if;
+    return null;
+end;
+$$;
+
+drop trigger if exists daily_records_rollup on public.daily_records;
+create trigger daily_records_rollup
+    after insert or update or delete on public.daily_records
+    for each row execute function public.daily_rollup_trigger();
+
+-- 从原始记录重建汇总表，p_user_id 为空时重建全部用户
+create or replace function public.rebuild_daily_rollups(p_user_id uuid default null)
+returns integer
+language plpgsql
+security definer
+set search_path = public
+as $$
+declare
+    v_rows integer;
+begin
+    delete from public.daily_rollups
+    where p_user_id is null or user_id = p_user_id;
+
+    insert into public.daily_rollups (user_id, day, tag, minutes, record_count)
+    select
+        r.user_id,
+        (coalesce(r.started_at, r.created_at) at time zone 'UTC')::date,
+        r.tag,
+        coalesce(sum(r.duration), 0)::integer,
+        count(*)::integer
+    from public.daily_records r
+    where p_user_id is null or r.user_id = p_user_id
+    group by 1, 2, 3;
+
+    get diagnostics v_rows = row_count;
+    return v_rows;
+end;
+$$;
+
+revoke execute on function public.rebuild_daily_rollups(uuid) from public, anon, authenticated;
+grant execute on function public.rebuild_daily_rollups(uuid) to service_role;
+
+select public.rebuild_daily_rollups();
@@ -0,0 +1,13 @@
+-- 用户偏好设置
+create table if not exists public.user_settings (
+    user_id    uuid primary key,
+    week_start text not null default 'monday' check (week_start in ('monday', 'sunday')),
+    timezone   text,
+    updated_at timestamptz not null default now()
+);
+
+alter table public.user_settings enable row level security;
+
+drop policy if exists "user_settings_own" on public.user_settings;
+create policy "user_settings_own" on public.user_settings
+    for all using (auth.uid() = user_id) with check (auth.uid() = user_id);
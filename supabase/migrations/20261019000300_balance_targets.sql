@@ -0,0 +1,3 @@
+-- 生活平衡目标: 各标签期望占总时长的百分比，如 {"工作": 40, "学习": 20}
+alter table public.user_settings
+    add column if not exists balance_targets jsonb not null default '{}'::jsonb;
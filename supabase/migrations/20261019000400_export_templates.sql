@@ -0,0 +1,18 @@
+-- 用户自定义导出模板 (Go text/template 语法)，导出周报/年报时通过 template_id 选用
+create table if not exists public.export_templates (
+    id         uuid primary key default gen_random_uuid(),
+    user_id    uuid        not null,
+    name       text        not null check (char_length(name) <= 50),
+    kind       text        not null check (kind in ('week', 'year')),
+    body       text        not null check (octet_length(body) <= 20480),
+    created_at timestamptz not null default now(),
+    updated_at timestamptz not null default now()
+);
+
+create index if not exists export_templates_user_idx on public.export_templates (user_id, kind);
+
+alter table public.export_templates enable row level security;
+
+drop policy if exists "export_templates_own" on public.export_templates;
+create policy "export_templates_own" on public.export_templates
+    for all using (auth.uid() = user_id) with check (auth.uid() = user_id);
@@ -0,0 +1,13 @@
+-- 日历订阅令牌: 日历应用无法携带 Bearer 令牌，订阅地址中带上随机令牌作为凭证。
+-- 只保存令牌的 SHA-256 摘要；每个用户最多一个令牌，重新生成或删除即吊销旧地址。
+create table if not exists public.feed_tokens (
+    user_id    uuid primary key,
+    token_hash text        not null unique,
+    created_at timestamptz not null default now()
+);
+
+alter table public.feed_tokens enable row level security;
+
+drop policy if exists "feed_tokens_own" on public.feed_tokens;
+create policy "feed_tokens_own" on public.feed_tokens
+    for all using (auth.uid() = user_id) with check (auth.uid() = user_id);
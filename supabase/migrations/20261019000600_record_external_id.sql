@@ -0,0 +1,7 @@
+-- 外部来源标识: 从日历等外部数据导入的记录保存来源事件的 UID，重复导入时据此去重
+alter table public.daily_records
+    add column if not exists external_id text;
+
+create unique index if not exists daily_records_user_external_idx
+    on public.daily_records (user_id, external_id)
+    where external_id is not null;
@@ -0,0 +1,38 @@
+-- 统一统计口径: 记录的时间取 coalesce(started_at, created_at)，范围归属与分桶都以此为准。
+-- daily_rollups 已按该时间归入日期；stats_hourly 此前按 created_at 筛选范围、按开始时间分桶，
+-- 范围边缘的记录 (跨日补记) 会因读取路径不同而统计不一致，这里改为按同一时间筛选。
+
+create index if not exists daily_records_user_time_idx
+    on public.daily_records (user_id, (coalesce(started_at, created_at)));
+
+-- stats_hourly 按 (用户时区下的小时, 标签) 聚合记录
+-- 筛选条件与 Go 端一致: coalesce(started_at, created_at) 位于 [p_from, p_to)
+-- bucket 为该小时在 p_tz 时区下的起点 (带时区时间戳)
+create or replace function public.stats_hourly(
+    p_user_id uuid,
+    p_from    timestamptz,
+    p_to      timestamptz,
+    p_tz      text default 'UTC'
+)
+returns table (
+    bucket       timestamptz,
+    tag          text,
+    record_count integer,
+    minutes      integer
+)
+language sql
+stable
+security invoker
+as $$
+    select
+        date_trunc('hour', coalesce(r.started_at, r.created_at) at time zone p_tz) at time zone p_tz as bucket,
+        r.tag::text,
+        count(*)::integer as record_count,
+        coalesce(sum(r.duration), 0)::integer as minutes
+    from public.daily_records r
+    where r.user_id = p_user_id
+      and coalesce(r.started_at, r.created_at) >= p_from
+      and coalesce(r.started_at, r.created_at) < p_to
+    group by 1, 2
+    order by 1, 2;
+$$;
@@ -0,0 +1,262 @@
+// Package tmpl 在受限环境中执行用户提供的 text/template 导出模板
+//
+// text/template 本身无法访问文件、网络或任意函数，模板只能调用 Funcs 中列出的函数。
+// 在此基础上再限制模板大小、结构复杂度、执行时间和输出长度，避免恶意或写错的模板拖垮服务。
+package tmpl
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+const (
+	// MaxBodySize 模板正文的最大字节数
+	MaxBodySize = 20 << 10
+	// MaxOutputSize 渲染结果的最大字节数
+	MaxOutputSize = 1 << 20
+	// Timeout 单次渲染的最长时间
+	Timeout = 2 * time.Second
+	// MaxRangeDepth range 的最大嵌套层数 (包括经由子模板的嵌套)
+	MaxRangeDepth = 2
+	// maxExpandedNodes 展开子模板调用后的最大语法节点数
+	maxExpandedNodes = 20000
+	// maxRepeat repeat/bar 单次生成的最大字符数
+	maxRepeat = 200
+	// MaxWrites 单次渲染的最多写出次数 (每次 range 迭代至少写出一次，因此也限制了迭代次数)
+	MaxWrites = 1000000
+)
+
+var (
+	// ErrTooLarge 模板正文过大
+	ErrTooLarge = fmt.Errorf("模板不能超过 %d 字节", MaxBodySize)
+	// ErrOutputTooLarge 渲染结果过大
+	ErrOutputTooLarge = fmt.Errorf("渲染结果不能超过 %d 字节", MaxOutputSize)
+	// ErrTimeout 渲染超时
+	ErrTimeout = fmt.Errorf("模板渲染超过 %s", Timeout)
+	// ErrTooManySteps 渲染中的迭代次数过多
+	ErrTooManySteps = errors.New("模板执行步骤过多")
+)
+
+// Funcs 模板中可以使用的函数
+var Funcs = template.FuncMap{
+	// hours 分钟换算为小时 (保留一位小数)
+	"hours": func(minutes int) float64 { return math.Round(float64(minutes)/6) / 10 },
+	// round 保留 n 位小数
+	"round": func(v float64, n int) float64 {
+		p := math.Pow(10, float64(n))
+		return math.Round(v*p) / p
+	},
+	"add": func(a, b int) int { return a + b },
+	"sub": func(a, b int) int { return a - b },
+	// pad 右侧补空格到 n 个字符
+	"pad": func(s string, n int) string {
+		if n > maxRepeat {
+			n = maxRepeat
+		}
+		if l := len([]rune(s)); l < n {
+			return s + strings.Repeat(" ", n-l)
+		}
+		return s
+	},
+	"repeat": func(s string, n int) string {
+		if n <= 0 || len(s)*n > maxRepeat*4 {
+			return ""
+		}
+		return strings.Repeat(s, n)
+	},
+	// bar 按比例生成文本条形图，如 {{bar .Percent 100 20}}
+	"bar": func(value, max float64, width int) string {
+		if max <= 0 || value <= 0 || width <= 0 {
+			return ""
+		}
+		if width > maxRepeat {
+			width = maxRepeat
+		}
+		n := int(math.Round(math.Min(value/max, 1) * float64(width)))
+		return strings.Repeat("█", n)
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+// Parse 校验并解析模板
+func Parse(body string) (*template.Template, error) {
+	if len(body) > MaxBodySize {
+		return nil, ErrTooLarge
+	}
+	t, err := template.New("export").Funcs(Funcs).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("模板语法错误: %w", err)
+	}
+	if err := checkComplexity(t); err != nil {
+		return nil, err
+	}
+	for _, sub := range t.Templates() {
+		if sub.Tree != nil {
+			markRanges(sub.Tree.Root)
+		}
+	}
+	return t, nil
+}
+
+// markRanges 在每个 range 循环体开头插入空文本节点
+//
+// text/template 执行到文本节点时总会调用 Write (即使内容为空)，这样每次迭代都会经过
+// limitedWriter: 超时取消或迭代次数超过 MaxWrites 后，没有输出的循环也会在下一次迭代时终止。
+func markRanges(n parse.Node) {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			markRanges(child)
+		}
+	case *parse.IfNode:
+		markRanges(n.List)
+		markRanges(n.ElseList)
+	case *parse.WithNode:
+		markRanges(n.List)
+		markRanges(n.ElseList)
+	case *parse.RangeNode:
+		markRanges(n.List)
+		markRanges(n.ElseList)
+		if n.List != nil {
+			mark := &parse.TextNode{NodeType: parse.NodeText, Pos: n.Pos, Text: []byte{}}
+			n.List.Nodes = append([]parse.Node{mark}, n.List.Nodes...)
+		}
+	}
+}
+
+// checkComplexity 拒绝递归调用子模板、嵌套过深的 range 以及展开后过于庞大的模板
+//
+// 限制结构后执行时间只与数据量成多项式关系；没有输出的循环由 markRanges 保证可以被中断。
+func checkComplexity(t *template.Template) error {
+	trees := make(map[string]*parse.Tree)
+	for _, sub := range t.Templates() {
+		if sub.Tree != nil {
+			trees[sub.Name()] = sub.Tree
+		}
+	}
+
+	visited := 0
+	var walk func(n parse.Node, depth int, stack []string) error
+	walk = func(n parse.Node, depth int, stack []string) error {
+		// 子模板按调用展开计数，防止层层多次调用造成指数级展开
+		if visited++; visited > maxExpandedNodes {
+			return errors.New("模板过于复杂")
+		}
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return nil
+			}
+			for _, child := range n.Nodes {
+				if err := walk(child, depth, stack); err != nil {
+					return err
+				}
+			}
+		case *parse.IfNode:
+			return walkBranch(walk, &n.BranchNode, depth, stack)
+		case *parse.WithNode:
+			return walkBranch(walk, &n.BranchNode, depth, stack)
+		case *parse.RangeNode:
+			if depth+1 > MaxRangeDepth {
+				return fmt.Errorf("range 嵌套不能超过 %d 层", MaxRangeDepth)
+			}
+			if err := walk(n.List, depth+1, stack); err != nil {
+				return err
+			}
+			return walk(n.ElseList, depth, stack)
+		case *parse.TemplateNode:
+			for _, name := range stack {
+				if name == n.Name {
+					return errors.New("模板不能递归调用自身")
+				}
+			}
+			if tree := trees[n.Name]; tree != nil {
+				return walk(tree.Root, depth, append(stack, n.Name))
+			}
+		}
+		return nil
+	}
+	if t.Tree == nil {
+		return nil
+	}
+	return walk(t.Tree.Root, 0, []string{t.Name()})
+}
+
+func walkBranch(walk func(parse.Node, int, []string) error, b *parse.BranchNode, depth int, stack []string) error {
+	if err := walk(b.List, depth, stack); err != nil {
+		return err
+	}
+	return walk(b.ElseList, depth, stack)
+}
+
+// Render 解析并执行模板，超时或输出过大时返回错误
+func Render(body string, data interface{}) (string, error) {
+	t, err := Parse(body)
+	if err != nil {
+		return "", err
+	}
+
+	w := &limitedWriter{}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("模板执行失败: %v", r)
+			}
+		}()
+		done <- t.Execute(w, data)
+	}()
+
+	timer := time.NewTimer(Timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		if errors.Is(err, ErrOutputTooLarge) {
+			return "", ErrOutputTooLarge
+		}
+		if errors.Is(err, ErrTooManySteps) {
+			return "", ErrTooManySteps
+		}
+		if err != nil {
+			return "", fmt.Errorf("模板执行失败: %w", err)
+		}
+		return w.buf.String(), nil
+	case <-timer.C:
+		// 执行中的模板在下一次写出 (至迟在下一次 range 迭代) 时终止
+		w.cancelled.Store(true)
+		return "", ErrTimeout
+	}
+}
+
+// limitedWriter 限制输出长度与写出次数，取消后拒绝写入以尽快结束模板执行
+type limitedWriter struct {
+	buf       bytes.Buffer
+	writes    int
+	cancelled atomic.Bool
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.cancelled.Load() {
+		return 0, ErrTimeout
+	}
+	if w.writes++; w.writes > MaxWrites {
+		return 0, ErrTooManySteps
+	}
+	if w.buf.Len()+len(p) > MaxOutputSize {
+		return 0, ErrOutputTooLarge
+	}
+	return w.buf.Write(p)
+}
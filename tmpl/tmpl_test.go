@@ -0,0 +1,120 @@
+package tmpl
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testData struct {
+	Title   string
+	Records []testRecord
+}
+
+type testRecord struct {
+	Tag     string
+	Minutes int
+}
+
+func TestRender(t *testing.T) {
+	data := testData{
+		Title:   "周总结",
+		Records: []testRecord{{"工作", 90}, {"学习", 45}},
+	}
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"{{.Title}}", "周总结"},
+		{"{{range .Records}}{{.Tag}}:{{hours .Minutes}} {{end}}", "工作:1.5 学习:0.8 "},
+		{"{{range .Records}}{{range $.Records}}x{{end}}{{end}}", "xxxx"},
+		{`{{define "row"}}[{{.Tag}}]{{end}}{{range .Records}}{{template "row" .}}{{end}}`, "[工作][学习]"},
+		{`{{pad "ab" 4}}|{{repeat "-" 3}}|{{bar 50 100 4}}|{{upper "x"}}`, "ab  |---|██|X"},
+		{`{{repeat "-" 1000}}`, ""},
+	}
+	for _, tt := range tests {
+		got, err := Render(tt.body, data)
+		if err != nil || got != tt.want {
+			t.Errorf("Render(%q) = %q, %v, want %q", tt.body, got, err, tt.want)
+		}
+	}
+
+	if _, err := Render("{{.Missing}}", map[string]int{}); err == nil {
+		t.Error("缺少的键应返回错误")
+	}
+}
+
+func TestParseComplexity(t *testing.T) {
+	tests := []struct {
+		body    string
+		wantErr string
+	}{
+		{"{{range .}}{{range .}}{{end}}{{end}}", ""},
+		{"{{range .}}{{range .}}{{range .}}{{end}}{{end}}{{end}}", "range 嵌套"},
+		{`{{define "a"}}{{range .}}{{end}}{{end}}{{range .}}{{range .}}{{template "a" .}}{{end}}{{end}}`, "range 嵌套"},
+		{`{{define "a"}}{{template "a" .}}{{end}}{{template "a" .}}`, "递归"},
+		{`{{define "a"}}{{template "b" .}}{{end}}{{define "b"}}{{template "a" .}}{{end}}x`, ""},
+		{"{{if}}", "语法错误"},
+		{strings.Repeat("x", MaxBodySize+1), "字节"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.body)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("Parse(%.40q) = %v", tt.body, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Parse(%.40q) = %v, want containing %q", tt.body, err, tt.wantErr)
+		}
+	}
+
+	// 子模板层层调用多次，展开后节点数呈指数增长
+	var b strings.Builder
+	b.WriteString(`{{define "t0"}}x{{end}}`)
+	for i := 1; i <= 20; i++ {
+		b.WriteString(`{{define "t` + strconv.Itoa(i) + `"}}{{template "t` + strconv.Itoa(i-1) + `"}}{{template "t` + strconv.Itoa(i-1) + `"}}{{end}}`)
+	}
+	b.WriteString(`{{template "t20"}}`)
+	if _, err := Parse(b.String()); err == nil || !strings.Contains(err.Error(), "过于复杂") {
+		t.Errorf("指数展开的模板应被拒绝，得到 %v", err)
+	}
+}
+
+func TestRenderSilentLoopStops(t *testing.T) {
+	records := make([]testRecord, 5000)
+	data := testData{Records: records}
+
+	// 5000 x 5000 次迭代没有任何输出，需在迭代次数上限处终止
+	start := time.Now()
+	_, err := Render("{{range .Records}}{{range $.Records}}{{end}}{{end}}", data)
+	if !errors.Is(err, ErrTooManySteps) {
+		t.Fatalf("err = %v, want ErrTooManySteps", err)
+	}
+	if elapsed := time.Since(start); elapsed > Timeout {
+		t.Errorf("耗时 %s，超过 %s", elapsed, Timeout)
+	}
+
+	if _, err := Render("{{range 100000000}}{{end}}", nil); !errors.Is(err, ErrTooManySteps) {
+		t.Errorf("range 整数: err = %v, want ErrTooManySteps", err)
+	}
+}
+
+func TestLimitedWriterCancelled(t *testing.T) {
+	w := &limitedWriter{}
+	if _, err := w.Write(nil); err != nil {
+		t.Fatal(err)
+	}
+	w.cancelled.Store(true)
+	if _, err := w.Write(nil); !errors.Is(err, ErrTimeout) {
+		t.Errorf("取消后的空写入应返回 ErrTimeout，得到 %v", err)
+	}
+
+	w = &limitedWriter{}
+	if _, err := w.Write(make([]byte, MaxOutputSize+1)); !errors.Is(err, ErrOutputTooLarge) {
+		t.Errorf("err = %v, want ErrOutputTooLarge", err)
+	}
+}
@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DateLayout 接口中日期参数的统一格式
+const DateLayout = "2006-01-02"
+
+// timestampLayouts Supabase 返回的时间戳可能出现的格式
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999",
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05",
+}
+
+// ParseDate 解析 "2026-02-21" 格式的日期
+func ParseDate(s string) (time.Time, error) {
+	return time.Parse(DateLayout, s)
+}
+
+// ParseYear 解析年份参数，仅接受 1970-9999
+func ParseYear(s string) (int, error) {
+	year, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if year < 1970 || year > 9999 {
+		return 0, errors.New("year out of range")
+	}
+	return year, nil
+}
+
+// ParseDateRange 解析闭区间日期范围，要求 from <= to 且跨度不超过 maxDays 天
+func ParseDateRange(fromStr, toStr string, maxDays int) (time.Time, time.Time, error) {
+	from, err := ParseDate(fromStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	to, err := ParseDate(toStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("to is before from")
+	}
+	if maxDays > 0 && DaysBetween(from, to) > maxDays {
+		return time.Time{}, time.Time{}, errors.New("range too long")
+	}
+	return from, to, nil
+}
+
+// isoWeekPattern ISO 周的格式，如 2026-W07
+var isoWeekPattern = regexp.MustCompile(`^\d{4}-W\d{2}$`)
+
+// ParseISOWeek 解析 "2026-W07" 格式的 ISO 周，返回该周周一的日期
+func ParseISOWeek(s string) (time.Time, error) {
+	if !isoWeekPattern.MatchString(s) {
+		return time.Time{}, errors.New("invalid iso week")
+	}
+	year, _ := strconv.Atoi(s[:4])
+	week, _ := strconv.Atoi(s[6:])
+
+	// 1 月 4 日总在第 1 周内
+	jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(week-1)*7)
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, errors.New("iso week out of range")
+	}
+	return monday, nil
+}
+
+// DaysBetween 返回闭区间 [from, to] 包含的天数
+func DaysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+// Today 返回 loc 时区下的今天 (以 UTC 零点表示的日期，便于与 ParseDate 的结果比较)
+func Today(loc *time.Location) time.Time {
+	return DateOf(time.Now().In(loc))
+}
+
+// DateOf 返回 t 在其自身时区下的日期 (以 UTC 零点表示)
+func DateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ParseTimestamp 解析记录中的时间戳，不带时区的按 UTC 处理
+func ParseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// LoadLocation 加载时区，name 为空时使用 DEFAULT_TIMEZONE 环境变量，均未设置则为 UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		name = os.Getenv("DEFAULT_TIMEZONE")
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseISOWeek(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // 空表示应返回错误
+	}{
+		{"2026-W01", "2025-12-29"},
+		{"2026-W07", "2026-02-09"},
+		{"2026-W53", "2026-12-28"},
+		{"2025-W53", ""},
+		{"2020-W53", "2020-12-28"},
+		{"2026-W00", ""},
+		{"2026-W7", ""},
+		{"2026-W7x", ""},
+		{"2026-W+7", ""},
+		{"2026-w07", ""},
+		{" 2026-W07", ""},
+		{"2026-W070", ""},
+	}
+	for _, tt := range tests {
+		got, err := ParseISOWeek(tt.in)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("ParseISOWeek(%q) = %s, want error", tt.in, got.Format(DateLayout))
+			}
+			continue
+		}
+		if err != nil || got.Format(DateLayout) != tt.want {
+			t.Errorf("ParseISOWeek(%q) = %s, %v, want %s", tt.in, got.Format(DateLayout), err, tt.want)
+		}
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	tests := []struct {
+		from, to string
+		maxDays  int
+		ok       bool
+	}{
+		{"2026-01-01", "2026-01-31", 31, true},
+		{"2026-01-01", "2026-02-01", 31, false},
+		{"2026-01-01", "2026-01-01", 1, true},
+		{"2026-01-02", "2026-01-01", 0, false},
+		{"2026-01-01", "2036-01-01", 0, true},
+		{"2026-1-1", "2026-01-31", 0, false},
+		{"", "2026-01-31", 0, false},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseDateRange(tt.from, tt.to, tt.maxDays)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseDateRange(%q, %q, %d) err = %v, want ok = %v", tt.from, tt.to, tt.maxDays, err, tt.ok)
+		}
+	}
+}
+
+func TestParseYear(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"2026", true},
+		{"1970", true},
+		{"1969", false},
+		{"10000", false},
+		{"20x6", false},
+	}
+	for _, tt := range tests {
+		if _, err := ParseYear(tt.in); (err == nil) != tt.ok {
+			t.Errorf("ParseYear(%q) err = %v, want ok = %v", tt.in, err, tt.ok)
+		}
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	from, _ := ParseDate("2026-03-01")
+	to, _ := ParseDate("2026-03-31")
+	if n := DaysBetween(from, to); n != 31 {
+		t.Errorf("DaysBetween = %d, want 31", n)
+	}
+	if n := DaysBetween(from, from); n != 1 {
+		t.Errorf("DaysBetween 同一天 = %d, want 1", n)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	want := time.Date(2026, 2, 21, 8, 30, 0, 0, time.UTC)
+	for _, s := range []string{
+		"2026-02-21T08:30:00Z",
+		"2026-02-21T16:30:00+08:00",
+		"2026-02-21T08:30:00.000000",
+		"2026-02-21 16:30:00+08",
+		"2026-02-21 08:30:00",
+	} {
+		got, ok := ParseTimestamp(s)
+		if !ok || !got.Equal(want) {
+			t.Errorf("ParseTimestamp(%q) = %v, %v, want %v", s, got, ok, want)
+		}
+	}
+	if _, ok := ParseTimestamp("2026/02/21"); ok {
+		t.Error("ParseTimestamp 应拒绝未知格式")
+	}
+}
+
+func TestDateOf(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*3600)
+	got := DateOf(time.Date(2026, 2, 21, 23, 30, 0, 0, loc))
+	if !got.Equal(time.Date(2026, 2, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("DateOf = %v", got)
+	}
+}
@@ -1,7 +1,14 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/supabase-community/postgrest-go"
 	"github.com/supabase-community/supabase-go"
@@ -9,10 +16,16 @@ import (
 
 var Client *supabase.Client
 
+var (
+	supabaseURL string
+	supabaseKey string
+	rpcClient   = &http.Client{Timeout: 30 * time.Second}
+)
+
 // InitSupabase 初始化 Supabase 客户端
 func InitSupabase() {
-	supabaseURL := os.Getenv("SUPABASE_URL")
-	supabaseKey := os.Getenv("SUPABASE_KEY")
+	supabaseURL = strings.TrimRight(os.Getenv("SUPABASE_URL"), "/")
+	supabaseKey = os.Getenv("SUPABASE_KEY")
 
 	if supabaseURL == "" || supabaseKey == "" {
 		panic("SUPABASE_URL and SUPABASE_KEY must be set")
@@ -27,3 +40,44 @@ func InitSupabase() {
 
 // OrderOptions 排序配置 (使用类型别名以兼容 postgrest)
 type OrderOptions = postgrest.OrderOpts
+
+// CallRPC 调用数据库函数 (PostgREST /rpc)，并将结果解析到 to
+//
+// 不使用 Client.Rpc: 它会吞掉 HTTP 错误码，且网络错误会写入共享客户端的
+// ClientError，导致之后所有查询都失败。
+func CallRPC(name string, params interface{}, to interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, supabaseURL+"/rest/v1/rpc/"+name, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("apikey", supabaseKey)
+	req.Header.Set("Authorization", "Bearer "+supabaseKey)
+
+	resp, err := rpcClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var pgErr postgrest.ExecuteError
+		if json.Unmarshal(respBody, &pgErr) == nil && pgErr.Message != "" {
+			return fmt.Errorf("rpc %s: (%s) %s", name, pgErr.Code, pgErr.Message)
+		}
+		return fmt.Errorf("rpc %s: status %d", name, resp.StatusCode)
+	}
+	if to == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, to)
+}
@@ -0,0 +1,339 @@
+// Package xlsx 以纯 Go 生成简单的 Excel 工作簿 (Office Open XML)
+//
+// 只覆盖导出需要的功能: 多个工作表、文本/数字/日期单元格、列宽、表头样式与冻结首行，
+// 不依赖 cgo 或第三方库，可在 Alpine 容器中运行。
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// MaxSheetNameLen Excel 工作表名称的最大长度
+const MaxSheetNameLen = 31
+
+// Workbook 工作簿
+type Workbook struct {
+	sheets []*Sheet
+}
+
+// New 创建空工作簿
+func New() *Workbook {
+	return &Workbook{}
+}
+
+// AddSheet 添加工作表，名称中的非法字符会被替换，超长部分会被截断
+func (wb *Workbook) AddSheet(name string) *Sheet {
+	s := &Sheet{name: sheetName(name, len(wb.sheets)+1)}
+	wb.sheets = append(wb.sheets, s)
+	return s
+}
+
+// Write 将工作簿以 .xlsx 格式写入 w
+func (wb *Workbook) Write(w io.Writer) error {
+	if len(wb.sheets) == 0 {
+		wb.AddSheet("Sheet1")
+	}
+
+	zw := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", wb.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", wb.workbookXML()},
+		{"xl/_rels/workbook.xml.rels", wb.workbookRels()},
+		{"xl/styles.xml", stylesXML},
+	}
+	for _, f := range files {
+		if err := writeZipFile(zw, f.name, f.content); err != nil {
+			return err
+		}
+	}
+	for i, s := range wb.sheets {
+		fw, err := zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := s.write(fw); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Sheet 工作表
+type Sheet struct {
+	name   string
+	widths []float64
+	header bool
+	rows   [][]Cell
+}
+
+// SetWidths 按顺序设置各列宽度 (单位为字符数)
+func (s *Sheet) SetWidths(widths ...float64) {
+	s.widths = widths
+}
+
+// SetHeader 写入加粗的表头行并冻结首行
+func (s *Sheet) SetHeader(titles ...string) {
+	row := make([]Cell, len(titles))
+	for i, t := range titles {
+		row[i] = Cell{kind: kindString, s: t, style: styleHeader}
+	}
+	s.header = true
+	s.rows = append([][]Cell{row}, s.rows...)
+}
+
+// AddRow 追加一行
+func (s *Sheet) AddRow(cells ...Cell) {
+	s.rows = append(s.rows, cells)
+}
+
+func (s *Sheet) write(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if s.header {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	if len(s.widths) > 0 {
+		b.WriteString("<cols>")
+		for i, width := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, i+1, i+1, width)
+		}
+		b.WriteString("</cols>")
+	}
+	b.WriteString("<sheetData>")
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			cell.write(&b, cellRef(c, r))
+		}
+		b.WriteString("</row>")
+
+		// 大表分段写出，避免整张表在内存中拼成一个字符串
+		if b.Len() > 64<<10 {
+			if _, err := io.WriteString(w, b.String()); err != nil {
+				return err
+			}
+			b.Reset()
+		}
+	}
+	b.WriteString("</sheetData>")
+	if s.header && len(s.rows) > 0 && len(s.rows[0]) > 0 {
+		fmt.Fprintf(&b, `<autoFilter ref="A1:%s"/>`, cellRef(len(s.rows[0])-1, len(s.rows)-1))
+	}
+	b.WriteString("</worksheet>")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// 单元格样式，对应 stylesXML 中 cellXfs 的下标
+const (
+	styleDefault = iota
+	styleHeader
+	styleDate
+	styleDateTime
+	styleDecimal
+	stylePercent
+)
+
+type cellKind int
+
+const (
+	kindEmpty cellKind = iota
+	kindString
+	kindNumber
+)
+
+// Cell 单元格
+type Cell struct {
+	kind  cellKind
+	s     string
+	n     float64
+	style int
+}
+
+// Empty 空单元格
+func Empty() Cell {
+	return Cell{}
+}
+
+// String 文本单元格
+func String(s string) Cell {
+	return Cell{kind: kindString, s: s}
+}
+
+// Int 整数单元格
+func Int(n int) Cell {
+	return Cell{kind: kindNumber, n: float64(n)}
+}
+
+// Decimal 保留两位小数显示的数字单元格
+func Decimal(f float64) Cell {
+	return Cell{kind: kindNumber, n: f, style: styleDecimal}
+}
+
+// Percent 百分比单元格 (f 为 0-1 的比例)
+func Percent(f float64) Cell {
+	return Cell{kind: kindNumber, n: f, style: stylePercent}
+}
+
+// Date 日期单元格 (只取 t 的年月日)
+func Date(t time.Time) Cell {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return Cell{kind: kindNumber, n: serial(day), style: styleDate}
+}
+
+// DateTime 日期时间单元格 (按 t 自身时区的墙上时间)
+func DateTime(t time.Time) Cell {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+	return Cell{kind: kindNumber, n: serial(wall), style: styleDateTime}
+}
+
+// excelEpoch Excel 日期序列号的起点 (兼容 1900 年闰年问题)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// serial 将 UTC 墙上时间换算为 Excel 日期序列号
+func serial(t time.Time) float64 {
+	return t.Sub(excelEpoch).Hours() / 24
+}
+
+func (c Cell) write(b *strings.Builder, ref string) {
+	switch c.kind {
+	case kindString:
+		fmt.Fprintf(b, `<c r="%s" t="inlineStr"`, ref)
+		if c.style != styleDefault {
+			fmt.Fprintf(b, ` s="%d"`, c.style)
+		}
+		b.WriteString(`><is><t xml:space="preserve">`)
+		xml.EscapeText(b, []byte(cleanText(c.s)))
+		b.WriteString("</t></is></c>")
+	case kindNumber:
+		fmt.Fprintf(b, `<c r="%s"`, ref)
+		if c.style != styleDefault {
+			fmt.Fprintf(b, ` s="%d"`, c.style)
+		}
+		fmt.Fprintf(b, `><v>%s</v></c>`, formatNumber(c.n))
+	}
+}
+
+// formatNumber 数字的 XML 表示
+func formatNumber(f float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.10f", f), "0"), ".")
+}
+
+// cleanText 去掉 XML 1.0 不允许的控制字符
+func cleanText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// cellRef 由 0 起始的列、行下标生成单元格引用，如 (0,0) => A1
+func cellRef(col, row int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return fmt.Sprintf("%s%d", name, row+1)
+}
+
+// sheetName 清理工作表名称
+func sheetName(name string, index int) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return fmt.Sprintf("Sheet%d", index)
+	}
+	if runes := []rune(name); len(runes) > MaxSheetNameLen {
+		name = string(runes[:MaxSheetNameLen])
+	}
+	return name
+}
+
+func writeZipFile(zw *zip.Writer, name, content string) error {
+	fw, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(fw, content)
+	return err
+}
+
+func (wb *Workbook) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString("</Types>")
+	return b.String()
+}
+
+func (wb *Workbook) workbookXML() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range wb.sheets {
+		b.WriteString(`<sheet name="`)
+		xml.EscapeText(&b, []byte(s.name))
+		fmt.Fprintf(&b, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	b.WriteString("</sheets></workbook>")
+	return b.String()
+}
+
+func (wb *Workbook) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.sheets)+1)
+	b.WriteString("</Relationships>")
+	return b.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// stylesXML cellXfs 顺序与 style* 常量一致
+const stylesXML = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/><numFmt numFmtId="165" formatCode="yyyy-mm-dd hh:mm"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFE7EEF7"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="6">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="10" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
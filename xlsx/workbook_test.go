@@ -0,0 +1,113 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCellRef(t *testing.T) {
+	tests := []struct {
+		col, row int
+		want     string
+	}{
+		{0, 0, "A1"},
+		{25, 9, "Z10"},
+		{26, 0, "AA1"},
+		{51, 1, "AZ2"},
+		{52, 2, "BA3"},
+		{701, 0, "ZZ1"},
+		{702, 0, "AAA1"},
+	}
+	for _, tt := range tests {
+		if got := cellRef(tt.col, tt.row); got != tt.want {
+			t.Errorf("cellRef(%d, %d) = %s, want %s", tt.col, tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"记录", "记录"},
+		{" a/b:c ", "a_b_c"},
+		{"", "Sheet3"},
+		{strings.Repeat("长", 40), strings.Repeat("长", MaxSheetNameLen)},
+	}
+	for _, tt := range tests {
+		if got := sheetName(tt.name, 3); got != tt.want {
+			t.Errorf("sheetName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCellValues(t *testing.T) {
+	tests := []struct {
+		cell Cell
+		want string
+	}{
+		{Int(42), `<c r="A1"><v>42</v></c>`},
+		{Decimal(1.5), `<c r="A1" s="4"><v>1.5</v></c>`},
+		{Date(time.Date(2026, 2, 16, 23, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))), `<c r="A1" s="2"><v>46069</v></c>`},
+		{DateTime(time.Date(1900, 1, 1, 12, 0, 0, 0, time.UTC)), `<c r="A1" s="3"><v>2.5</v></c>`},
+		{String("a<b\x01"), `<c r="A1" t="inlineStr"><is><t xml:space="preserve">a&lt;b</t></is></c>`},
+		{Empty(), ""},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		tt.cell.write(&b, "A1")
+		if got := b.String(); got != tt.want {
+			t.Errorf("write(%+v) = %s, want %s", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	wb := New()
+	s := wb.AddSheet("记录")
+	s.SetHeader("日期", "时长")
+	s.AddRow(String("2026-02-16"), Int(30))
+	wb.AddSheet("汇总")
+
+	var buf bytes.Buffer
+	if err := wb.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("生成的文件不是有效的 zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("缺少 %s", name)
+		}
+	}
+	if wbXML := files["xl/workbook.xml"]; !strings.Contains(wbXML, `name="记录"`) || !strings.Contains(wbXML, `name="汇总" sheetId="2"`) {
+		t.Errorf("workbook.xml = %s", wbXML)
+	}
+	if sheet := files["xl/worksheets/sheet1.xml"]; !strings.Contains(sheet, `<c r="B2"><v>30</v></c>`) {
+		t.Errorf("sheet1.xml = %s", sheet)
+	}
+
+	// 没有工作表时自动添加一个空表
+	buf.Reset()
+	if err := New().Write(&buf); err != nil || buf.Len() == 0 {
+		t.Errorf("空工作簿写入失败: %v", err)
+	}
+}